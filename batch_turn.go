@@ -0,0 +1,253 @@
+package smartturn
+
+import (
+	"sync"
+	"time"
+
+	ort "github.com/yalue/onnxruntime_go"
+
+	"github.com/cortexswarm/smart-turn-go/features"
+)
+
+// BatchTurnConfig configures a BatchTurn.
+type BatchTurnConfig struct {
+	// MaxBatch is the largest number of segments scored together (e.g. 16).
+	MaxBatch int
+	// MaxWait is how long a segment waits for others to join its batch
+	// (e.g. 20 * time.Millisecond). It bounds the latency batching adds.
+	MaxWait time.Duration
+}
+
+// BatchTurnStats reports BatchTurn throughput and queueing. Divide
+// Predictions by Batches for the mean batch size.
+type BatchTurnStats struct {
+	Batches     uint64        // session runs
+	Predictions uint64        // segments scored, including failed runs
+	Errors      uint64        // segments whose run failed
+	Queued      int           // segments waiting for a batch now
+	TotalWait   time.Duration // summed time from submission to the start of the segment's run
+	MaxWait     time.Duration // longest such wait
+	TotalRun    time.Duration // summed session run time
+}
+
+// BatchTurn is a shared Smart-Turn service that scores segments from many
+// engines in one (B, 80, 800) run. Each engine gets its own predictor from
+// NewPredictor (Config.TurnPredictor); mel features are computed on the
+// caller's goroutine, and the predictor blocks until its batch has run, so
+// Config.AsyncTurnPrediction keeps the wait off the audio path. BatchTurn is
+// safe for concurrent use.
+type BatchTurn struct {
+	session *sharedSession
+	batcher *batcher[*turnRequest]
+
+	mu     sync.Mutex
+	stats  BatchTurnStats
+	closed bool
+
+	tensors map[int]*batchTurnTensors // by batch size; used only by the batcher loop
+}
+
+// turnRequest is one predictor's segment. The predictor blocks on done while
+// the batcher owns the request.
+type turnRequest struct {
+	mel       []float32 // whisperNMels × whisper8sFrames, owned by the predictor
+	submitted time.Time
+	prob      float32
+	err       error
+	done      chan struct{}
+}
+
+type batchTurnTensors struct {
+	inputs, outputs []ort.Value
+	input           *ort.Tensor[float32] // (n, 80, 800)
+	output          *ort.Tensor[float32] // (n, 1)
+}
+
+// NewBatchTurn starts a batcher on the Smart-Turn session in models, which
+// must have been loaded with a SmartTurnModelPath. Close it after the engines
+// using its predictors; it does not close models.
+func NewBatchTurn(models *Models, cfg BatchTurnConfig) (*BatchTurn, error) {
+	switch {
+	case models == nil || models.turn == nil:
+		return nil, configError("Models", "Models has no Smart-Turn model")
+	case cfg.MaxBatch <= 0:
+		return nil, configError("MaxBatch", "MaxBatch must be > 0")
+	case cfg.MaxWait <= 0:
+		return nil, configError("MaxWait", "MaxWait must be > 0")
+	}
+	b := &BatchTurn{
+		session: models.turn,
+		tensors: make(map[int]*batchTurnTensors),
+	}
+	b.batcher = newBatcher(cfg.MaxBatch, cfg.MaxWait, nil, b.run, b.fail, ErrBatchClosed)
+	return b, nil
+}
+
+// NewPredictor returns a predictor for one engine, to be set as
+// Config.TurnPredictor. The engine closes it; closing it does not affect
+// other engines.
+func (b *BatchTurn) NewPredictor() TurnPredictor {
+	return &batchPredictor{
+		batch: b,
+		mel:   newTurnFeatures(),
+		req: turnRequest{
+			mel:  make([]float32, whisperNMels*whisper8sFrames),
+			done: make(chan struct{}, 1),
+		},
+	}
+}
+
+// Stats returns a snapshot of the throughput and queueing counters.
+func (b *BatchTurn) Stats() BatchTurnStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// Close stops the batcher after the batch in progress. Predictors fail with
+// ErrBatchClosed afterwards.
+func (b *BatchTurn) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+	b.batcher.close()
+	for n, t := range b.tensors {
+		_ = destroyValues(t.inputs, t.outputs)
+		delete(b.tensors, n)
+	}
+	return nil
+}
+
+// run scores batch with one session call and wakes its predictors.
+func (b *BatchTurn) run(batch []*turnRequest) {
+	start := time.Now()
+	b.mu.Lock()
+	for _, r := range batch {
+		wait := start.Sub(r.submitted)
+		b.stats.TotalWait += wait
+		if wait > b.stats.MaxWait {
+			b.stats.MaxWait = wait
+		}
+	}
+	b.mu.Unlock()
+
+	t, err := b.batchTensors(len(batch))
+	if err != nil {
+		b.fail(batch, err)
+		return
+	}
+	in := t.input.GetData()
+	size := whisperNMels * whisper8sFrames
+	for i, r := range batch {
+		copy(in[i*size:(i+1)*size], r.mel)
+	}
+	err = b.session.run(t.inputs, t.outputs)
+	elapsed := time.Since(start)
+
+	b.mu.Lock()
+	b.stats.Batches++
+	b.stats.TotalRun += elapsed
+	b.mu.Unlock()
+	if err != nil {
+		b.fail(batch, err)
+		return
+	}
+	b.record(len(batch), 0)
+	out := t.output.GetData()
+	for i, r := range batch {
+		r.prob, r.err = out[i], nil
+		r.done <- struct{}{}
+	}
+}
+
+func (b *BatchTurn) fail(batch []*turnRequest, err error) {
+	b.record(len(batch), len(batch))
+	for _, r := range batch {
+		r.err = err
+		r.done <- struct{}{}
+	}
+}
+
+// record counts n answered requests, failed of them with errors.
+func (b *BatchTurn) record(n, failed int) {
+	b.mu.Lock()
+	b.stats.Predictions += uint64(n)
+	b.stats.Errors += uint64(failed)
+	b.stats.Queued -= n
+	b.mu.Unlock()
+}
+
+// batchTensors returns the tensors for batch size n, creating them on first use.
+func (b *BatchTurn) batchTensors(n int) (*batchTurnTensors, error) {
+	if t := b.tensors[n]; t != nil {
+		return t, nil
+	}
+	input, err := ort.NewEmptyTensor[float32](ort.NewShape(int64(n), whisperNMels, whisper8sFrames))
+	if err != nil {
+		return nil, err
+	}
+	output, err := ort.NewEmptyTensor[float32](ort.NewShape(int64(n), 1))
+	if err != nil {
+		_ = input.Destroy()
+		return nil, err
+	}
+	t := &batchTurnTensors{
+		inputs:  []ort.Value{input},
+		outputs: []ort.Value{output},
+		input:   input,
+		output:  output,
+	}
+	b.tensors[n] = t
+	return t, nil
+}
+
+// batchPredictor is one engine's TurnPredictor on a BatchTurn. Not safe for
+// concurrent use, which the engine guarantees.
+type batchPredictor struct {
+	batch  *BatchTurn
+	mel    *features.Extractor
+	req    turnRequest
+	closed bool
+}
+
+// PredictTurn computes the segment's features and waits for its batch.
+func (p *batchPredictor) PredictTurn(seg TurnSegment) (TurnResult, error) {
+	if p.closed {
+		return TurnResult{}, ErrBatchClosed
+	}
+	r := &p.req
+	if err := turnFeatures(p.mel, seg, r.mel); err != nil {
+		return TurnResult{}, err
+	}
+	r.submitted = time.Now()
+	b := p.batch
+	b.mu.Lock()
+	b.stats.Queued++
+	b.mu.Unlock()
+	if !b.batcher.submit(r) {
+		b.mu.Lock()
+		b.stats.Queued--
+		b.mu.Unlock()
+		return TurnResult{}, ErrBatchClosed
+	}
+	if !b.batcher.wait(r.done) {
+		b.mu.Lock()
+		b.stats.Queued--
+		b.mu.Unlock()
+		return TurnResult{}, ErrBatchClosed
+	}
+	if r.err != nil {
+		return TurnResult{}, r.err
+	}
+	return smartTurnResult(r.prob), nil
+}
+
+// Close detaches the predictor.
+func (p *batchPredictor) Close() error {
+	p.closed = true
+	return nil
+}
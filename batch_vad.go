@@ -0,0 +1,274 @@
+package smartturn
+
+import (
+	"sync"
+	"time"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// BatchVADConfig configures a BatchVAD.
+type BatchVADConfig struct {
+	// MaxBatch is the largest number of frames run together (e.g. 64).
+	MaxBatch int
+	// MaxWait bounds the latency batching adds: a frame waits at most this
+	// long for others to join its batch (e.g. 5 * time.Millisecond). A batch
+	// also runs as soon as every open detector has submitted a frame.
+	MaxWait time.Duration
+	// SampleRate is the rate of every detector's frames: RequiredSampleRate
+	// (512-sample chunks) or NarrowbandSampleRate (256-sample chunks). It must
+	// match the engines' Config.ChunkSize.
+	SampleRate int
+}
+
+// BatchVAD runs Silero VAD for many streams with one batched session call.
+// Each engine gets its own detector from NewDetector (Config.VAD); a detector
+// keeps its stream's recurrent state and audio context, submits its frame
+// and blocks until the batch holding it has run. Engines must therefore push
+// audio from separate goroutines (e.g. one Worker each) for frames to batch.
+// BatchVAD is safe for concurrent use.
+type BatchVAD struct {
+	session *sharedSession
+	frame   sileroFrame
+	batcher *batcher[*batchFrame]
+
+	mu      sync.Mutex
+	streams int // open detectors
+	closed  bool
+
+	tensors map[int]*batchTensors // by batch size; used only by the batcher loop
+}
+
+// batchFrame is one detector's request. The detector blocks on done while
+// loop owns the frame.
+type batchFrame struct {
+	input []float32                // context + chunk
+	state [sileroStateSize]float32 // in: current state; out: stateN
+	prob  float32
+	err   error
+	done  chan struct{}
+}
+
+// batchTensors are the session inputs and outputs for one batch size n.
+type batchTensors struct {
+	inputs, outputs []ort.Value
+	input           *ort.Tensor[float32] // (n, 576), or (n, 288) at 8 kHz
+	state           *ort.Tensor[float32] // (2, n, 128)
+	output          *ort.Tensor[float32] // (n, 1)
+	stateOut        *ort.Tensor[float32] // (2, n, 128)
+}
+
+// NewBatchVAD starts a batcher on the Silero session in models, which must
+// have been loaded with a SileroVADModelPath. Close it after the engines
+// using its detectors; it does not close models.
+func NewBatchVAD(models *Models, cfg BatchVADConfig) (*BatchVAD, error) {
+	switch {
+	case models == nil || models.vad == nil:
+		return nil, configError("Models", "Models has no Silero VAD model")
+	case cfg.MaxBatch <= 0:
+		return nil, configError("MaxBatch", "MaxBatch must be > 0")
+	case cfg.MaxWait <= 0:
+		return nil, configError("MaxWait", "MaxWait must be > 0")
+	case cfg.SampleRate != RequiredSampleRate && cfg.SampleRate != NarrowbandSampleRate:
+		return nil, configError("SampleRate", "SampleRate must be 16000 or 8000")
+	}
+	frame := silero16k
+	if cfg.SampleRate == NarrowbandSampleRate {
+		frame = silero8k
+	}
+	b := &BatchVAD{
+		session: models.vad,
+		frame:   frame,
+		tensors: make(map[int]*batchTensors),
+	}
+	b.batcher = newBatcher(cfg.MaxBatch, cfg.MaxWait, b.allSubmitted, b.run, failFrames, ErrBatchClosed)
+	return b, nil
+}
+
+// NewDetector returns a detector for one stream, to be set as Config.VAD.
+// The engine closes it; closing it does not affect other streams.
+func (b *BatchVAD) NewDetector() VoiceActivityDetector {
+	b.mu.Lock()
+	b.streams++
+	b.mu.Unlock()
+	return &batchDetector{
+		batch:   b,
+		frame:   batchFrame{input: make([]float32, b.frame.inputSamples()), done: make(chan struct{}, 1)},
+		context: make([]float32, b.frame.context),
+	}
+}
+
+// Close stops the batcher after the batch in progress. Detectors fail with
+// ErrBatchClosed afterwards.
+func (b *BatchVAD) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+	b.batcher.close()
+	for n, t := range b.tensors {
+		_ = destroyValues(t.inputs, t.outputs)
+		delete(b.tensors, n)
+	}
+	return nil
+}
+
+// allSubmitted reports whether every open detector has a frame in the batch.
+func (b *BatchVAD) allSubmitted(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return n >= b.streams
+}
+
+// run scores batch with one session call and wakes its detectors.
+func (b *BatchVAD) run(batch []*batchFrame) {
+	n := len(batch)
+	t, err := b.batchTensors(n)
+	if err != nil {
+		failFrames(batch, err)
+		return
+	}
+	// State is laid out (2, n, 128): each of the two layers holds n rows.
+	const layer = sileroStateSize / 2
+	in, st := t.input.GetData(), t.state.GetData()
+	for i, f := range batch {
+		copy(in[i*len(f.input):], f.input)
+		copy(st[i*layer:(i+1)*layer], f.state[:layer])
+		copy(st[(n+i)*layer:(n+i+1)*layer], f.state[layer:])
+	}
+	if err := b.session.run(t.inputs, t.outputs); err != nil {
+		failFrames(batch, err)
+		return
+	}
+	out, sn := t.output.GetData(), t.stateOut.GetData()
+	for i, f := range batch {
+		f.prob = out[i]
+		copy(f.state[:layer], sn[i*layer:(i+1)*layer])
+		copy(f.state[layer:], sn[(n+i)*layer:(n+i+1)*layer])
+		f.err = nil
+		f.done <- struct{}{}
+	}
+}
+
+func failFrames(batch []*batchFrame, err error) {
+	for _, f := range batch {
+		f.err = err
+		f.done <- struct{}{}
+	}
+}
+
+// batchTensors returns the tensors for batch size n, creating them on first use.
+func (b *BatchVAD) batchTensors(n int) (*batchTensors, error) {
+	if t := b.tensors[n]; t != nil {
+		return t, nil
+	}
+	input, err := ort.NewEmptyTensor[float32](ort.NewShape(int64(n), int64(b.frame.inputSamples())))
+	if err != nil {
+		return nil, err
+	}
+	state, err := ort.NewEmptyTensor[float32](ort.NewShape(2, int64(n), 128))
+	if err != nil {
+		_ = input.Destroy()
+		return nil, err
+	}
+	sr, err := ort.NewTensor(ort.NewShape(1), []int64{int64(b.frame.rate)})
+	if err != nil {
+		_ = destroyValues([]ort.Value{input, state})
+		return nil, err
+	}
+	output, err := ort.NewEmptyTensor[float32](ort.NewShape(int64(n), 1))
+	if err != nil {
+		_ = destroyValues([]ort.Value{input, state, sr})
+		return nil, err
+	}
+	stateOut, err := ort.NewEmptyTensor[float32](ort.NewShape(2, int64(n), 128))
+	if err != nil {
+		_ = destroyValues([]ort.Value{input, state, sr, output})
+		return nil, err
+	}
+	t := &batchTensors{
+		inputs:   []ort.Value{input, state, sr},
+		outputs:  []ort.Value{output, stateOut},
+		input:    input,
+		state:    state,
+		output:   output,
+		stateOut: stateOut,
+	}
+	b.tensors[n] = t
+	return t, nil
+}
+
+// batchDetector is one stream's VoiceActivityDetector on a BatchVAD. It
+// behaves like the single-stream Silero detector, including the periodic
+// state reset and the snapshot layout. Not safe for concurrent use.
+type batchDetector struct {
+	batch      *BatchVAD
+	frame      batchFrame
+	context    []float32
+	sinceReset int
+	closed     bool
+}
+
+// SpeechProbability submits frame to the batch and waits for its result.
+func (d *batchDetector) SpeechProbability(frame []float32) (float32, error) {
+	sf := d.batch.frame
+	if len(frame) != sf.chunk {
+		return 0, ErrChunkSize
+	}
+	if d.closed {
+		return 0, ErrBatchClosed
+	}
+	if d.sinceReset >= sf.resetSamples() {
+		d.Reset()
+	}
+	d.sinceReset += sf.chunk
+
+	f := &d.frame
+	copy(f.input[:sf.context], d.context)
+	copy(f.input[sf.context:], frame)
+	if !d.batch.batcher.submit(f) || !d.batch.batcher.wait(f.done) {
+		return 0, ErrBatchClosed
+	}
+	if f.err != nil {
+		return 0, f.err
+	}
+	copy(d.context, f.input[sf.chunk:])
+	return f.prob, nil
+}
+
+// Reset clears the stream's recurrent state and audio context.
+func (d *batchDetector) Reset() {
+	clear(d.context)
+	clear(d.frame.state[:])
+	d.sinceReset = 0
+}
+
+// MarshalBinary encodes the stream state in the single-stream Silero layout.
+func (d *batchDetector) MarshalBinary() ([]byte, error) {
+	return marshalSileroState(d.sinceReset, d.context, d.frame.state[:]), nil
+}
+
+// UnmarshalBinary restores state written by either Silero detector.
+func (d *batchDetector) UnmarshalBinary(data []byte) error {
+	sinceReset, err := unmarshalSileroState(data, d.context, d.frame.state[:])
+	if err != nil {
+		return err
+	}
+	d.sinceReset = sinceReset
+	return nil
+}
+
+// Close detaches the stream so batches no longer wait for it.
+func (d *batchDetector) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	d.batch.mu.Lock()
+	d.batch.streams--
+	d.batch.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,111 @@
+package smartturn
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBatchClosed is returned by detectors and predictors of a BatchVAD or
+// BatchTurn that has been closed.
+var ErrBatchClosed = errors.New("batch inference is closed")
+
+// batcher gathers requests submitted from many goroutines into batches that
+// one goroutine runs. A batch starts with the first waiting request and runs
+// when it holds maxBatch requests, when full reports it complete, or maxWait
+// after it started, whichever comes first. BatchVAD and BatchTurn build on it.
+type batcher[R any] struct {
+	maxBatch int
+	maxWait  time.Duration
+	full     func(n int) bool // optional early trigger
+	run      func(batch []R)  // must answer every request
+	fail     func(batch []R, err error)
+	closed   error // passed to fail for requests pending at close
+
+	requests chan R
+	quit     chan struct{}
+	done     chan struct{}
+	stop     sync.Once
+}
+
+func newBatcher[R any](maxBatch int, maxWait time.Duration, full func(int) bool,
+	run func([]R), fail func([]R, error), closed error) *batcher[R] {
+	b := &batcher[R]{
+		maxBatch: maxBatch,
+		maxWait:  maxWait,
+		full:     full,
+		run:      run,
+		fail:     fail,
+		closed:   closed,
+		requests: make(chan R, maxBatch),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go b.loop()
+	return b
+}
+
+func (b *batcher[R]) loop() {
+	defer close(b.done)
+	batch := make([]R, 0, b.maxBatch)
+	timer := time.NewTimer(b.maxWait)
+	timer.Stop()
+	for {
+		batch = batch[:0]
+		select {
+		case r := <-b.requests:
+			batch = append(batch, r)
+		case <-b.quit:
+			return
+		}
+		timer.Reset(b.maxWait)
+	fill:
+		for len(batch) < b.maxBatch && (b.full == nil || !b.full(len(batch))) {
+			select {
+			case r := <-b.requests:
+				batch = append(batch, r)
+			case <-timer.C:
+				break fill
+			case <-b.quit:
+				timer.Stop()
+				b.fail(batch, b.closed)
+				return
+			}
+		}
+		timer.Stop()
+		b.run(batch)
+	}
+}
+
+// submit queues r and reports false if the batcher has been closed.
+func (b *batcher[R]) submit(r R) bool {
+	select {
+	case b.requests <- r:
+		return true
+	case <-b.quit:
+		return false
+	}
+}
+
+// wait blocks until the request answering on reply has been run or failed, and
+// reports false if the batcher stopped without taking it.
+func (b *batcher[R]) wait(reply <-chan struct{}) bool {
+	select {
+	case <-reply:
+		return true
+	case <-b.done:
+		// The loop answers every request it took before exiting.
+		select {
+		case <-reply:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// close stops the loop after the batch in progress and waits for it.
+func (b *batcher[R]) close() {
+	b.stop.Do(func() { close(b.quit) })
+	<-b.done
+}
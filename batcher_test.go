@@ -0,0 +1,230 @@
+package smartturn
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchReq is a stand-in for turnRequest and batchFrame: run doubles in,
+// and the submitter waits on done like the batch predictors do.
+type batchReq struct {
+	in, out int
+	err     error
+	done    chan struct{}
+}
+
+// fakeBatcher runs batches without ONNX, recording their sizes. Each batch
+// is answered in reverse order, so answers cannot rely on submission order.
+type fakeBatcher struct {
+	*batcher[*batchReq]
+	mu      sync.Mutex
+	sizes   []int
+	entered chan int      // if set, each run sends its batch size, then
+	gate    chan struct{} // waits for a value
+}
+
+func newFakeBatcher(maxBatch int, maxWait time.Duration, full func(int) bool) *fakeBatcher {
+	f := &fakeBatcher{}
+	run := func(batch []*batchReq) {
+		if f.gate != nil {
+			f.entered <- len(batch)
+			<-f.gate
+		}
+		f.mu.Lock()
+		f.sizes = append(f.sizes, len(batch))
+		f.mu.Unlock()
+		for i := len(batch) - 1; i >= 0; i-- {
+			batch[i].out = 2 * batch[i].in
+			batch[i].done <- struct{}{}
+		}
+	}
+	fail := func(batch []*batchReq, err error) {
+		for _, r := range batch {
+			r.err = err
+			r.done <- struct{}{}
+		}
+	}
+	f.batcher = newBatcher(maxBatch, maxWait, full, run, fail, ErrBatchClosed)
+	return f
+}
+
+// request submits in and waits for its answer, as batchPredictor does.
+func (f *fakeBatcher) request(in int) (*batchReq, error) {
+	r := &batchReq{in: in, done: make(chan struct{}, 1)}
+	if !f.submit(r) || !f.wait(r.done) {
+		return nil, ErrBatchClosed
+	}
+	return r, r.err
+}
+
+func (f *fakeBatcher) batchSizes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.sizes...)
+}
+
+// requestAll submits n requests concurrently and checks their answers.
+func (f *fakeBatcher) requestAll(t *testing.T, n int) {
+	t.Helper()
+	out := make([]*batchReq, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out[i], errs[i] = f.request(i)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out[i].out != 2*i {
+			t.Fatalf("request %d answered %d", i, out[i].out)
+		}
+	}
+}
+
+// TestBatcherFormsFullBatches sets MaxWait far away, so a batch runs only
+// once it holds MaxBatch requests or full reports it complete.
+func TestBatcherFormsFullBatches(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		full func(int) bool
+		want []int
+	}{
+		{"MaxBatch", nil, []int{4, 4, 4}},
+		{"full", func(n int) bool { return n == 3 }, []int{3, 3, 3, 3}},
+	} {
+		f := newFakeBatcher(4, time.Hour, c.full)
+		f.requestAll(t, 12)
+		if got := f.batchSizes(); !equalInts(got, c.want) {
+			t.Errorf("%s: batch sizes %v, want %v", c.name, got, c.want)
+		}
+		f.close()
+	}
+}
+
+// TestBatcherFlushesOnTimeout runs a batch that never fills once MaxWait has
+// passed since its first request.
+func TestBatcherFlushesOnTimeout(t *testing.T) {
+	const maxWait = 30 * time.Millisecond
+	f := newFakeBatcher(8, maxWait, nil)
+	defer f.close()
+	start := time.Now()
+	f.requestAll(t, 3)
+	if elapsed := time.Since(start); elapsed < maxWait {
+		t.Errorf("partial batch ran after %v, before MaxWait %v", elapsed, maxWait)
+	}
+	if got := f.batchSizes(); len(got) == 0 || got[0] > 3 || sum(got) != 3 {
+		t.Errorf("batch sizes %v, want the 3 requests in partial batches", got)
+	}
+}
+
+// TestBatcherRoutesResults runs many streams, each waiting for its own
+// answer before submitting the next request: every stream must get the
+// answer to its own request.
+func TestBatcherRoutesResults(t *testing.T) {
+	const streams, perStream = 16, 50
+	f := newFakeBatcher(5, time.Millisecond, nil)
+	defer f.close()
+	var wg sync.WaitGroup
+	errs := make(chan error, streams)
+	for s := 0; s < streams; s++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perStream; i++ {
+				in := s*1000 + i
+				r, err := f.request(in)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if r.out != 2*in {
+					errs <- errors.New("stream got another stream's answer")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if got := sum(f.batchSizes()); got != streams*perStream {
+		t.Errorf("ran %d requests, want %d", got, streams*perStream)
+	}
+}
+
+// TestBatcherClosePending closes the batcher while a batch runs and another
+// request is queued: the running batch is answered, the queued request fails
+// with ErrBatchClosed whether or not the loop took it, and later submissions
+// are refused.
+func TestBatcherClosePending(t *testing.T) {
+	f := newFakeBatcher(2, time.Hour, nil)
+	f.entered, f.gate = make(chan int), make(chan struct{})
+	running := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := f.request(i)
+			running <- err
+		}()
+	}
+	if n := <-f.entered; n != 2 {
+		t.Fatalf("running batch of %d, want 2", n)
+	}
+	// While run blocks, the next request waits in the queue for a batch.
+	filling := make(chan error, 1)
+	go func() {
+		_, err := f.request(10)
+		filling <- err
+	}()
+	for len(f.requests) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		f.close()
+		close(closed)
+	}()
+	f.gate <- struct{}{}
+	<-closed
+	for i := 0; i < 2; i++ {
+		if err := <-running; err != nil {
+			t.Errorf("request in the running batch: %v", err)
+		}
+	}
+	if err := <-filling; !errors.Is(err, ErrBatchClosed) {
+		t.Errorf("pending request: err = %v, want ErrBatchClosed", err)
+	}
+	if _, err := f.request(20); !errors.Is(err, ErrBatchClosed) {
+		t.Errorf("request after close: err = %v, want ErrBatchClosed", err)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sum(x []int) int {
+	var s int
+	for _, v := range x {
+		s += v
+	}
+	return s
+}
@@ -1,23 +1,87 @@
 package smartturn
 
+import "time"
+
 // Callbacks are invoked synchronously by the engine from the same goroutine
-// that calls PushPCM. The SDK does not spawn goroutines. All fields are
-// optional (nil is allowed).
+// that calls PushPCM. The SDK does not spawn goroutines, except the Smart-Turn
+// worker enabled by Config.AsyncTurnPrediction, whose results are still
+// delivered on the caller's goroutine. All fields are
+// optional (nil is allowed). Engine.Events offers the same events as a
+// channel of typed Event values.
+//
+// Every callback argument carries stream time: the offset from the first
+// sample pushed to the engine (or since the last Reset), measured in VAD
+// samples (16 kHz, or 8 kHz with NarrowbandChunkSize) and reported as a
+// time.Duration.
 type Callbacks struct {
-	OnListeningStarted func()
-	OnListeningStopped func()
+	OnListeningStarted func(ev ListeningStarted)
+	OnListeningStopped func(ev ListeningStopped)
 
-	OnSpeechStart func()
-	OnSpeechEnd   func()
+	OnSpeechStart func(ev SpeechStart)
+	OnSpeechEnd   func(ev SpeechEnd)
 
-	OnChunk        func(chunk []float32)
-	// OnSegmentReady receives segment audio; the engine may reuse the slice after the callback returns—copy if retaining.
-	OnSegmentReady func(segment []float32)
+	OnChunk func(ev Chunk)
+	// OnSegmentReady receives segment audio; the engine may reuse ev.Audio after the callback returns—copy if retaining.
+	OnSegmentReady func(ev SegmentReady)
 
-	// OnTurnPrediction receives Smart-Turn's decision when a segment ends by VAD
-	// silence (not by max-duration cap). `complete` is true when the model
-	// thinks the turn is finished; `probability` is the underlying score.
-	OnTurnPrediction func(complete bool, probability float32)
+	// OnTurnPrediction receives the TurnPredictor's decision when a segment ends by VAD
+	// silence (not by max-duration cap). ev.Complete is true when the model
+	// thinks the turn is finished; ev.Probability is the underlying score.
+	OnTurnPrediction func(ev TurnPrediction)
 
+	// OnError receives *StageError values identifying the failing stage.
 	OnError func(err error)
+
+	// OnOverflow is invoked by a Worker when audio was dropped because its queue was full.
+	OnOverflow func(ev Overflow)
+}
+
+// ListeningStarted is passed to OnListeningStarted.
+type ListeningStarted struct {
+	At time.Duration // stream time when listening started
+}
+
+// ListeningStopped is passed to OnListeningStopped.
+type ListeningStopped struct {
+	At time.Duration // stream time when listening stopped
+}
+
+// SpeechStart is passed to OnSpeechStart.
+type SpeechStart struct {
+	At        time.Duration // start of the first voiced chunk
+	PreRollAt time.Duration // start of the segment including VadPreSpeechMs of pre-roll
+}
+
+// SpeechEnd is passed to OnSpeechEnd.
+type SpeechEnd struct {
+	At time.Duration // end of the last voiced chunk of the turn
+}
+
+// Chunk is passed to OnChunk for every chunk processed while listening, at the
+// VAD rate (16 kHz, or 8 kHz with NarrowbandChunkSize).
+// Audio is only valid for the duration of the callback.
+type Chunk struct {
+	Audio []float32
+	Start time.Duration
+}
+
+// SegmentReady is passed to OnSegmentReady. Audio is mono at the VAD rate
+// covering [Start, End) in stream time.
+type SegmentReady struct {
+	Audio      []float32
+	Start, End time.Duration
+}
+
+// TurnPrediction is passed to OnTurnPrediction. Start and End bound the
+// segment Smart-Turn was run on.
+type TurnPrediction struct {
+	Complete    bool
+	Probability float32
+	Start, End  time.Duration
+	Metadata    map[string]string // TurnResult.Metadata from the predictor
+}
+
+// sampleTime converts a sample offset at rate to stream time.
+func sampleTime(samples int64, rate int) time.Duration {
+	return time.Duration(samples) * time.Second / time.Duration(rate)
 }
@@ -0,0 +1,138 @@
+package smartturn
+
+import "math"
+
+// ChannelPolicy selects how interleaved multi-channel input is reduced to the
+// mono stream the models analyse.
+type ChannelPolicy int
+
+const (
+	// ChannelAverage averages all channels (the zero value).
+	ChannelAverage ChannelPolicy = iota
+	// ChannelSelect uses only Config.Channel. Run one engine per channel to
+	// analyse each side of a stereo call recording separately.
+	ChannelSelect
+	// ChannelLoudest follows the channel with the highest running energy,
+	// switching with hysteresis. Useful for mic arrays and two-mic headsets.
+	ChannelLoudest
+)
+
+func (p ChannelPolicy) String() string {
+	switch p {
+	case ChannelAverage:
+		return "average"
+	case ChannelSelect:
+		return "select"
+	case ChannelLoudest:
+		return "loudest"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	loudestEnergyTau    = 0.3  // seconds; smoothing of per-channel energy
+	loudestDecisionMs   = 20   // how often ChannelLoudest reconsiders its pick
+	loudestSwitchFactor = 2.0  // energy ratio (~3 dB) a channel needs to take over
+	loudestEnergyFloor  = 1e-9 // ignore channels quieter than this
+)
+
+// downmixer converts interleaved multi-channel samples to mono according to a
+// ChannelPolicy. A trailing partial frame is carried to the next call. Not
+// safe for concurrent use.
+type downmixer struct {
+	channels int
+	policy   ChannelPolicy
+	channel  int // ChannelSelect: fixed pick; ChannelLoudest: current pick
+
+	partial    []float32 // samples of an incomplete interleaved frame
+	partialLen int
+	out        []float32 // reusable mono output
+
+	// ChannelLoudest state.
+	energy       []float64
+	alpha        float64
+	decideFrames int
+	untilDecide  int
+}
+
+func newDownmixer(channels int, policy ChannelPolicy, channel, sampleRate int) *downmixer {
+	d := &downmixer{
+		channels: channels,
+		policy:   policy,
+		channel:  channel,
+		partial:  make([]float32, channels),
+	}
+	if policy == ChannelLoudest {
+		d.channel = 0
+		d.energy = make([]float64, channels)
+		d.alpha = math.Exp(-1 / (loudestEnergyTau * float64(sampleRate)))
+		d.decideFrames = max(1, sampleRate*loudestDecisionMs/1000)
+		d.untilDecide = d.decideFrames
+	}
+	return d
+}
+
+// process downmixes interleaved samples and returns mono samples in a buffer
+// that is reused by the next call.
+func (d *downmixer) process(samples []float32) []float32 {
+	d.out = d.out[:0]
+	if d.partialLen > 0 {
+		n := copy(d.partial[d.partialLen:], samples)
+		d.partialLen += n
+		samples = samples[n:]
+		if d.partialLen < d.channels {
+			return d.out
+		}
+		d.out = append(d.out, d.mixFrame(d.partial))
+		d.partialLen = 0
+	}
+	for len(samples) >= d.channels {
+		d.out = append(d.out, d.mixFrame(samples[:d.channels]))
+		samples = samples[d.channels:]
+	}
+	d.partialLen = copy(d.partial, samples)
+	return d.out
+}
+
+func (d *downmixer) mixFrame(frame []float32) float32 {
+	switch d.policy {
+	case ChannelSelect:
+		return frame[d.channel]
+	case ChannelLoudest:
+		for c, v := range frame {
+			d.energy[c] = d.alpha*d.energy[c] + (1-d.alpha)*float64(v)*float64(v)
+		}
+		d.untilDecide--
+		if d.untilDecide <= 0 {
+			d.untilDecide = d.decideFrames
+			best := d.channel
+			for c, en := range d.energy {
+				if en > d.energy[best] {
+					best = c
+				}
+			}
+			if best != d.channel && d.energy[best] > loudestEnergyFloor &&
+				d.energy[best] > loudestSwitchFactor*d.energy[d.channel] {
+				d.channel = best
+			}
+		}
+		return frame[d.channel]
+	default:
+		var sum float32
+		for _, v := range frame {
+			sum += v
+		}
+		return sum / float32(len(frame))
+	}
+}
+
+// reset drops any partial frame and, for ChannelLoudest, the energy history.
+func (d *downmixer) reset() {
+	d.partialLen = 0
+	if d.policy == ChannelLoudest {
+		clear(d.energy)
+		d.channel = 0
+		d.untilDecide = d.decideFrames
+	}
+}
@@ -1,19 +1,43 @@
 package smartturn
 
 import (
-	"errors"
+	"fmt"
 	"os"
 )
 
 const (
+	// RequiredSampleRate is the rate Smart-Turn runs at, and VAD unless
+	// ChunkSize is NarrowbandChunkSize. Input at any other Config.SampleRate
+	// is resampled to it.
 	RequiredSampleRate = 16000
 	RequiredChunkSize  = 512
+
+	// NarrowbandSampleRate and NarrowbandChunkSize select Silero's native
+	// 8 kHz mode: with ChunkSize 256, VAD and segmentation run on 256-sample
+	// (32 ms) chunks at 8 kHz, so telephony audio needs no upsampling, and
+	// segments are upsampled to 16 kHz only for the TurnPredictor.
+	NarrowbandSampleRate = 8000
+	NarrowbandChunkSize  = 256
 )
 
+// chunkRate returns the VAD and segmentation rate for a valid chunk size.
+func chunkRate(chunkSize int) int {
+	if chunkSize == NarrowbandChunkSize {
+		return NarrowbandSampleRate
+	}
+	return RequiredSampleRate
+}
+
 // Config holds SDK configuration. All fields must be set; no silent defaults.
 type Config struct {
-	SampleRate   int     // must be 16000
-	ChunkSize    int     // must be 512
+	SampleRate int // input rate in Hz (e.g. 8000, 16000, 44100, 48000); resampled to the VAD rate internally
+	ChunkSize  int // 512 (VAD at 16 kHz) or NarrowbandChunkSize, 256 (VAD at 8 kHz)
+	// Channels is the number of interleaved channels in pushed audio (1 = mono).
+	Channels int
+	// ChannelPolicy reduces multi-channel input to mono; ignored when Channels is 1.
+	ChannelPolicy ChannelPolicy
+	// Channel is the zero-based channel analysed when ChannelPolicy is ChannelSelect.
+	Channel      int
 	VadThreshold float32 // speech probability threshold (e.g. 0.5)
 
 	// VAD behaviour and buffering.
@@ -36,60 +60,142 @@ type Config struct {
 	// we skipped OnSpeechEnd, we invoke OnSpeechEnd (timeout).
 	TurnTimeoutMs int
 
-	SileroVADModelPath string // path to silero_vad.onnx
-	SmartTurnModelPath string // path to smart-turn-v3.2-cpu.onnx
+	// AsyncTurnPrediction runs Smart-Turn on a background goroutine so VAD and
+	// segmentation keep consuming audio during inference. The prediction and
+	// any resulting OnSpeechEnd are delivered, in stream order, from a later
+	// PushPCM/PushSamples call (Flush waits for them). If speech resumes before
+	// the result arrives, the result is discarded and the turn continues.
+	// Pushes never wait for inference; a segment that is stale before the
+	// worker reaches it is not scored.
+	AsyncTurnPrediction bool
+
+	SileroVADModelPath string // path to silero_vad.onnx; not required when VAD or Models is set
+
+	// VAD replaces the built-in Silero detector when non-nil. The engine takes
+	// ownership and closes it from Engine.Close.
+	VAD VoiceActivityDetector
+
+	// TurnPredictor replaces the built-in Smart-Turn model when non-nil. The
+	// engine takes ownership and closes it from Engine.Close.
+	TurnPredictor      TurnPredictor
+	SmartTurnModelPath string // path to smart-turn-v3.2-cpu.onnx; not required when TurnPredictor or Models is set
+
+	// Models shares model sessions loaded by LoadModels with other engines.
+	// When set, the model paths and ONNXRuntimeLibPath are not used and the
+	// engine does not close Models. It must hold every model not replaced by
+	// VAD or TurnPredictor.
+	Models *Models
 
 	// ONNXRuntimeLibPath is the path to the ONNX Runtime shared library (e.g. libonnxruntime.dylib).
 	// If empty, the SDK uses ONNXRUNTIME_SHARED_LIBRARY_PATH env var if set; otherwise onnxruntime_go default.
 	ONNXRuntimeLibPath string
 }
 
-// validate checks Config and returns an error on invalid or missing values.
+// validateConfig checks Config and returns a StageConfig error naming the
+// first invalid or missing field. Missing model files wrap fs.ErrNotExist.
 func validateConfig(cfg Config) error {
-	if cfg.SampleRate != RequiredSampleRate {
-		return errors.New("config: SampleRate must be 16000")
+	if cfg.SampleRate <= 0 {
+		return configError("SampleRate", "SampleRate must be > 0")
+	}
+	if cfg.ChunkSize != RequiredChunkSize && cfg.ChunkSize != NarrowbandChunkSize {
+		return configError("ChunkSize", "ChunkSize must be 512 or 256")
+	}
+	if up, _ := resampleRatio(cfg.SampleRate, chunkRate(cfg.ChunkSize)); up > maxResamplePhases {
+		return configError("SampleRate", fmt.Sprintf("SampleRate has no supported ratio to %d", chunkRate(cfg.ChunkSize)))
+	}
+	if cfg.Channels < 1 {
+		return configError("Channels", "Channels must be >= 1")
 	}
-	if cfg.ChunkSize != RequiredChunkSize {
-		return errors.New("config: ChunkSize must be 512")
+	switch cfg.ChannelPolicy {
+	case ChannelAverage, ChannelLoudest:
+	case ChannelSelect:
+		if cfg.Channel < 0 || cfg.Channel >= cfg.Channels {
+			return configError("Channel", "Channel must be in [0, Channels)")
+		}
+	default:
+		return configError("ChannelPolicy", "unknown ChannelPolicy")
 	}
 	if cfg.VadThreshold < 0 || cfg.VadThreshold > 1 {
-		return errors.New("config: VadThreshold must be in [0, 1]")
+		return configError("VadThreshold", "VadThreshold must be in [0, 1]")
 	}
 	if cfg.VadPreSpeechMs < 0 {
-		return errors.New("config: VadPreSpeechMs must be >= 0")
+		return configError("VadPreSpeechMs", "VadPreSpeechMs must be >= 0")
 	}
 	if cfg.VadStopMs <= 0 {
-		return errors.New("config: VadStopMs must be > 0")
+		return configError("VadStopMs", "VadStopMs must be > 0")
 	}
 	if cfg.TurnMaxDurationSeconds <= 0 {
-		return errors.New("config: TurnMaxDurationSeconds must be > 0")
+		return configError("TurnMaxDurationSeconds", "TurnMaxDurationSeconds must be > 0")
 	}
 	if cfg.TurnSegmentEmitMs <= 0 {
-		return errors.New("config: TurnSegmentEmitMs must be > 0")
+		return configError("TurnSegmentEmitMs", "TurnSegmentEmitMs must be > 0")
 	}
 	if cfg.TurnThreshold < 0 || cfg.TurnThreshold > 1 {
-		return errors.New("config: TurnThreshold must be in [0, 1]")
+		return configError("TurnThreshold", "TurnThreshold must be in [0, 1]")
 	}
 	if cfg.TurnTimeoutMs <= 0 {
-		return errors.New("config: TurnTimeoutMs must be > 0")
+		return configError("TurnTimeoutMs", "TurnTimeoutMs must be > 0")
+	}
+	if cfg.Models != nil {
+		if cfg.VAD == nil && cfg.Models.vad == nil {
+			return configError("Models", "Models has no Silero VAD model and VAD is not set")
+		}
+		if cfg.TurnPredictor == nil && cfg.Models.turn == nil {
+			return configError("Models", "Models has no Smart-Turn model and TurnPredictor is not set")
+		}
+		return nil
 	}
-	if cfg.SileroVADModelPath == "" {
-		return errors.New("config: SileroVADModelPath is required")
+	if cfg.VAD == nil && cfg.SileroVADModelPath == "" {
+		return configError("SileroVADModelPath", "SileroVADModelPath is required")
 	}
-	if cfg.SmartTurnModelPath == "" {
-		return errors.New("config: SmartTurnModelPath is required")
+	if cfg.TurnPredictor == nil && cfg.SmartTurnModelPath == "" {
+		return configError("SmartTurnModelPath", "SmartTurnModelPath is required")
 	}
-	if _, err := os.Stat(cfg.SileroVADModelPath); err != nil {
-		if os.IsNotExist(err) {
-			return errors.New("config: Silero VAD model file not found: " + cfg.SileroVADModelPath)
+	if cfg.VAD == nil {
+		if _, err := os.Stat(cfg.SileroVADModelPath); err != nil {
+			return &StageError{Stage: StageConfig, Field: "SileroVADModelPath", Err: fmt.Errorf("Silero VAD model file: %w", err)}
 		}
-		return err
 	}
-	if _, err := os.Stat(cfg.SmartTurnModelPath); err != nil {
-		if os.IsNotExist(err) {
-			return errors.New("config: Smart-Turn model file not found: " + cfg.SmartTurnModelPath)
+	if cfg.TurnPredictor == nil {
+		if _, err := os.Stat(cfg.SmartTurnModelPath); err != nil {
+			return &StageError{Stage: StageConfig, Field: "SmartTurnModelPath", Err: fmt.Errorf("Smart-Turn model file: %w", err)}
 		}
-		return err
 	}
 	return nil
 }
+
+// checkConfigUpdate validates next and rejects changes to fields that are
+// fixed when the engine is created.
+func checkConfigUpdate(cur, next Config) error {
+	if err := validateConfig(next); err != nil {
+		return err
+	}
+	var field string
+	switch {
+	case next.SampleRate != cur.SampleRate:
+		field = "SampleRate"
+	case next.ChunkSize != cur.ChunkSize:
+		field = "ChunkSize"
+	case next.Channels != cur.Channels:
+		field = "Channels"
+	case next.ChannelPolicy != cur.ChannelPolicy:
+		field = "ChannelPolicy"
+	case next.AsyncTurnPrediction != cur.AsyncTurnPrediction:
+		field = "AsyncTurnPrediction"
+	case (next.VAD == nil) != (cur.VAD == nil):
+		field = "VAD"
+	case (next.TurnPredictor == nil) != (cur.TurnPredictor == nil):
+		field = "TurnPredictor"
+	case next.Models != cur.Models:
+		field = "Models"
+	case next.SileroVADModelPath != cur.SileroVADModelPath:
+		field = "SileroVADModelPath"
+	case next.SmartTurnModelPath != cur.SmartTurnModelPath:
+		field = "SmartTurnModelPath"
+	case next.ONNXRuntimeLibPath != cur.ONNXRuntimeLibPath:
+		field = "ONNXRuntimeLibPath"
+	default:
+		return nil
+	}
+	return configError(field, field+" cannot change without a new engine")
+}
@@ -0,0 +1,215 @@
+package smartturn
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// levelProbVAD reports each frame's first sample as its speech probability.
+type levelProbVAD struct{}
+
+func (levelProbVAD) SpeechProbability(frame []float32) (float32, error) { return frame[0], nil }
+func (levelProbVAD) Reset()                                             {}
+func (levelProbVAD) Close() error                                       { return nil }
+
+// fixedPredictor scores every turn with probability p.
+type fixedPredictor struct{ p float32 }
+
+func (f fixedPredictor) PredictTurn(TurnSegment) (TurnResult, error) {
+	return TurnResult{Complete: f.p > 0.5, Probability: f.p}, nil
+}
+func (fixedPredictor) Close() error { return nil }
+
+// configUpdateConfig is a stereo ChannelSelect engine config over
+// levelProbVAD and a 0.6 fixedPredictor.
+func configUpdateConfig() Config {
+	c := testConfig(RequiredSampleRate, RequiredChunkSize)
+	c.Channels = 2
+	c.ChannelPolicy = ChannelSelect
+	c.VAD = levelProbVAD{}
+	c.TurnPredictor = fixedPredictor{p: 0.6}
+	return c
+}
+
+// pushLevels pushes n stereo chunks with constant left and right samples.
+func pushLevels(t *testing.T, e *Engine, n int, left, right float32) {
+	t.Helper()
+	chunk := make([]float32, 2*RequiredChunkSize)
+	for i := 0; i < len(chunk); i += 2 {
+		chunk[i], chunk[i+1] = left, right
+	}
+	for i := 0; i < n; i++ {
+		if err := e.PushSamples(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func hasEvent[T Event](events []Event) bool {
+	for _, ev := range events {
+		if _, ok := ev.(T); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TestUpdateConfigApplies changes a field mid-stream and pushes one more
+// chunk. That chunk must show the change, and the same stream without the
+// update must not.
+func TestUpdateConfigApplies(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		setup  func(c *Config)
+		before func(t *testing.T, e *Engine)
+		update func(c *Config)
+		after  func(t *testing.T, e *Engine)
+		want   func(events []Event, st State) bool
+	}{
+		{
+			name:  "VadStopMs ends an open segment",
+			setup: func(c *Config) { c.VadStopMs = 320 },
+			before: func(t *testing.T, e *Engine) {
+				pushLevels(t, e, 5, 1, 1)
+				pushLevels(t, e, 3, 0, 0)
+			},
+			update: func(c *Config) { c.VadStopMs = 64 },
+			after:  func(t *testing.T, e *Engine) { pushLevels(t, e, 1, 0, 0) },
+			want:   func(ev []Event, _ State) bool { return hasEvent[TurnPrediction](ev) },
+		},
+		{
+			name:   "TurnMaxDurationSeconds caps an open segment",
+			before: func(t *testing.T, e *Engine) { pushLevels(t, e, 40, 1, 1) },
+			update: func(c *Config) { c.TurnMaxDurationSeconds = 1 },
+			after:  func(t *testing.T, e *Engine) { pushLevels(t, e, 1, 1, 1) },
+			want:   func(ev []Event, _ State) bool { return hasEvent[SpeechEnd](ev) },
+		},
+		{
+			name:   "VadThreshold reclassifies speech",
+			before: func(t *testing.T, e *Engine) { pushLevels(t, e, 5, 0.6, 0.6) },
+			update: func(c *Config) { c.VadThreshold = 0.7 },
+			after:  func(t *testing.T, e *Engine) { pushLevels(t, e, 1, 0.6, 0.6) },
+			want:   func(_ []Event, st State) bool { return st.TrailingSilence > 0 },
+		},
+		{
+			name: "TurnThreshold keeps the turn pending",
+			before: func(t *testing.T, e *Engine) {
+				pushLevels(t, e, 5, 1, 1)
+				pushLevels(t, e, 1, 0, 0)
+			},
+			update: func(c *Config) { c.TurnThreshold = 0.7 },
+			after:  func(t *testing.T, e *Engine) { pushLevels(t, e, 1, 0, 0) },
+			want: func(ev []Event, st State) bool {
+				return hasEvent[TurnPrediction](ev) && !hasEvent[SpeechEnd](ev) && st.TurnPending
+			},
+		},
+		{
+			name:  "TurnTimeoutMs ends a pending turn",
+			setup: func(c *Config) { c.TurnThreshold = 0.7 },
+			before: func(t *testing.T, e *Engine) {
+				pushLevels(t, e, 5, 1, 1)
+				pushLevels(t, e, 5, 0, 0)
+			},
+			update: func(c *Config) { c.TurnTimeoutMs = 64 },
+			after:  func(t *testing.T, e *Engine) { pushLevels(t, e, 1, 0, 0) },
+			want:   func(ev []Event, _ State) bool { return hasEvent[SpeechEnd](ev) },
+		},
+		{
+			name:   "Channel selects the other channel",
+			before: func(t *testing.T, e *Engine) { pushLevels(t, e, 1, 0, 1) },
+			update: func(c *Config) { c.Channel = 1 },
+			after:  func(t *testing.T, e *Engine) { pushLevels(t, e, 1, 0, 1) },
+			want:   func(_ []Event, st State) bool { return st.LastVADProbability == 1 },
+		},
+	} {
+		run := func(update bool) ([]Event, State) {
+			cfg := configUpdateConfig()
+			if tc.setup != nil {
+				tc.setup(&cfg)
+			}
+			var events []Event
+			e, err := New(cfg, recordEvents(&events))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer e.Close()
+			e.Start()
+			tc.before(t, e)
+			if update {
+				next := e.Config()
+				tc.update(&next)
+				if err := e.UpdateConfig(next); err != nil {
+					t.Fatalf("%s: %v", tc.name, err)
+				}
+			}
+			if tc.want(events, e.State()) {
+				t.Fatalf("%s: took effect before the next chunk", tc.name)
+			}
+			n := len(events)
+			tc.after(t, e)
+			return events[n:], e.State()
+		}
+		if ev, st := run(false); tc.want(ev, st) {
+			t.Errorf("%s: happens without the update", tc.name)
+		}
+		if ev, st := run(true); !tc.want(ev, st) {
+			t.Errorf("%s: not applied on the next chunk; events %v, state %+v", tc.name, ev, st)
+		}
+	}
+}
+
+// TestUpdateConfigRejects changes each field that needs a new engine, and
+// one that fails validation: UpdateConfig and Worker.UpdateConfig must
+// return a StageConfig error naming it and leave the config unchanged.
+func TestUpdateConfigRejects(t *testing.T) {
+	// Dropping VAD or TurnPredictor needs a model file to pass validation.
+	model := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(model, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		field  string
+		modify func(c *Config)
+	}{
+		{"SampleRate", func(c *Config) { c.SampleRate = 48000 }},
+		{"ChunkSize", func(c *Config) { c.ChunkSize = NarrowbandChunkSize }},
+		{"Channels", func(c *Config) { c.Channels, c.Channel = 1, 0 }},
+		{"ChannelPolicy", func(c *Config) { c.ChannelPolicy = ChannelLoudest }},
+		{"AsyncTurnPrediction", func(c *Config) { c.AsyncTurnPrediction = true }},
+		{"VAD", func(c *Config) { c.VAD, c.SileroVADModelPath = nil, model }},
+		{"TurnPredictor", func(c *Config) { c.TurnPredictor, c.SmartTurnModelPath = nil, model }},
+		{"Models", func(c *Config) { c.Models = &Models{} }},
+		{"SileroVADModelPath", func(c *Config) { c.SileroVADModelPath = "silero_vad.onnx" }},
+		{"SmartTurnModelPath", func(c *Config) { c.SmartTurnModelPath = "smart-turn-v3.2-cpu.onnx" }},
+		{"ONNXRuntimeLibPath", func(c *Config) { c.ONNXRuntimeLibPath = "libonnxruntime.so" }},
+		{"VadThreshold", func(c *Config) { c.VadThreshold = 2 }},
+	} {
+		e, err := New(configUpdateConfig(), Callbacks{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := NewWorker(e, WorkerConfig{QueueSize: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cur := e.Config()
+		next := cur
+		tc.modify(&next)
+		for name, err := range map[string]error{
+			"Engine": e.UpdateConfig(next),
+			"Worker": w.UpdateConfig(next),
+		} {
+			var se *StageError
+			if !errors.As(err, &se) || se.Stage != StageConfig || se.Field != tc.field {
+				t.Errorf("%s.UpdateConfig changing %s: err = %v, want a StageConfig error for the field", name, tc.field, err)
+			}
+		}
+		if !reflect.DeepEqual(e.Config(), cur) {
+			t.Errorf("rejected %s change altered the config", tc.field)
+		}
+		e.Close()
+	}
+}
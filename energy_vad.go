@@ -0,0 +1,202 @@
+package smartturn
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/cortexswarm/smart-turn-go/internal/fft"
+)
+
+// EnergyVADConfig tunes EnergyVAD. All fields must be set;
+// DefaultEnergyVADConfig returns values that suit close-talk microphones.
+type EnergyVADConfig struct {
+	// SNRThresholdDB is how far (in dB) frame energy must rise above the
+	// adaptive noise floor to count as speech (e.g. 9).
+	SNRThresholdDB float32
+	// MinEnergyDB is the absolute level in dBFS below which a frame is always
+	// silence, so digital silence does not drag the floor down to -inf (e.g. -55).
+	MinEnergyDB float32
+	// FlatnessThreshold is the spectral flatness (0 = tonal, 1 = white noise)
+	// below which a frame looks voiced (e.g. 0.4).
+	FlatnessThreshold float32
+	// ZCRThreshold is the zero-crossing rate (crossings per sample) below which
+	// a frame looks voiced (e.g. 0.25).
+	ZCRThreshold float32
+	// NoiseFloorRiseSeconds is the time constant with which the noise floor
+	// follows louder frames upward (e.g. 8). It follows quieter frames quickly.
+	NoiseFloorRiseSeconds float32
+	// InitialNoiseFloorDB is the noise floor at the start of a stream in dBFS (e.g. -60).
+	InitialNoiseFloorDB float32
+}
+
+// DefaultEnergyVADConfig returns a starting point for EnergyVAD.
+func DefaultEnergyVADConfig() EnergyVADConfig {
+	return EnergyVADConfig{
+		SNRThresholdDB:        9,
+		MinEnergyDB:           -55,
+		FlatnessThreshold:     0.4,
+		ZCRThreshold:          0.25,
+		NoiseFloorRiseSeconds: 8,
+		InitialNoiseFloorDB:   -60,
+	}
+}
+
+const (
+	energyVADFallDecay = 0.3    // per-frame weight when the floor follows a quieter frame
+	energyVADLowHz     = 150.0  // band used for spectral flatness
+	energyVADHighHz    = 4000.0 // (covers voiced speech harmonics)
+	energyVADEps       = 1e-12
+)
+
+// EnergyVAD is a pure-Go VoiceActivityDetector that needs neither ONNX Runtime
+// nor model files. It combines frame energy relative to an adaptive noise
+// floor with two voicing cues, zero-crossing rate and spectral flatness, into
+// a speech probability. It suits embedded and CI environments, or hosts where
+// the ONNX Runtime library cannot be shipped; Silero remains more accurate in
+// noise. Not safe for concurrent use.
+type EnergyVAD struct {
+	cfg   EnergyVADConfig
+	floor float64 // noise floor in dBFS
+
+	// Spectra for 512-sample 16 kHz frames and 256-sample 8 kHz frames; both
+	// span 32 ms, so bins are 31.25 Hz apart either way.
+	plan, plan8k     *fft.Plan
+	window, window8k []float64
+	re, im           []float64
+}
+
+// NewEnergyVAD validates cfg and returns a detector for 512-sample 16 kHz
+// frames or 256-sample 8 kHz frames (Config.ChunkSize NarrowbandChunkSize).
+func NewEnergyVAD(cfg EnergyVADConfig) (*EnergyVAD, error) {
+	switch {
+	case cfg.SNRThresholdDB <= 0:
+		return nil, configError("SNRThresholdDB", "SNRThresholdDB must be > 0")
+	case cfg.FlatnessThreshold <= 0 || cfg.FlatnessThreshold >= 1:
+		return nil, configError("FlatnessThreshold", "FlatnessThreshold must be in (0, 1)")
+	case cfg.ZCRThreshold <= 0 || cfg.ZCRThreshold >= 1:
+		return nil, configError("ZCRThreshold", "ZCRThreshold must be in (0, 1)")
+	case cfg.NoiseFloorRiseSeconds <= 0:
+		return nil, configError("NoiseFloorRiseSeconds", "NoiseFloorRiseSeconds must be > 0")
+	case cfg.MinEnergyDB >= 0:
+		return nil, configError("MinEnergyDB", "MinEnergyDB must be < 0 dBFS")
+	case cfg.InitialNoiseFloorDB >= 0:
+		return nil, configError("InitialNoiseFloorDB", "InitialNoiseFloorDB must be < 0 dBFS")
+	}
+	n := RequiredChunkSize
+	v := &EnergyVAD{
+		cfg:      cfg,
+		plan:     fft.NewPlan(n),
+		plan8k:   fft.NewPlan(NarrowbandChunkSize),
+		window:   hann(n),
+		window8k: hann(NarrowbandChunkSize),
+		re:       make([]float64, n),
+		im:       make([]float64, n),
+	}
+	v.Reset()
+	return v, nil
+}
+
+// hann returns a periodic Hann window of length n.
+func hann(n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n)))
+	}
+	return w
+}
+
+// SpeechProbability scores one 512-sample 16 kHz or 256-sample 8 kHz frame.
+// It does not allocate.
+func (v *EnergyVAD) SpeechProbability(frame []float32) (float32, error) {
+	if len(frame) != RequiredChunkSize && len(frame) != NarrowbandChunkSize {
+		return 0, ErrChunkSize
+	}
+	n := len(frame)
+
+	var sumSq float64
+	crossings := 0
+	for i, x := range frame {
+		sumSq += float64(x) * float64(x)
+		if i > 0 && (x >= 0) != (frame[i-1] >= 0) {
+			crossings++
+		}
+	}
+	energyDB := 10 * math.Log10(sumSq/float64(n)+energyVADEps)
+	// Crossings per 16 kHz sample, so ZCRThreshold means the same at 8 kHz.
+	zcr := float64(crossings) / float64(n-1) * float64(n) / RequiredChunkSize
+	flatness := v.flatness(frame)
+
+	// Loudness relative to the noise floor, gated by an absolute minimum.
+	snr := energyDB - v.floor
+	pLoud := sigmoid((snr-float64(v.cfg.SNRThresholdDB))/3) *
+		sigmoid((energyDB-float64(v.cfg.MinEnergyDB))/3)
+	// Voicing: either cue may vouch for the frame; fricatives have a high ZCR
+	// but the segmenter's VadStopMs hangover bridges them.
+	pFlat := sigmoid((float64(v.cfg.FlatnessThreshold) - flatness) / 0.05)
+	pZCR := sigmoid((float64(v.cfg.ZCRThreshold) - zcr) / 0.03)
+	prob := pLoud * (0.4 + 0.6*math.Max(pFlat, pZCR))
+
+	// Track the floor: fall quickly to quieter frames, rise slowly otherwise,
+	// and never below MinEnergyDB so silence does not make noise look loud.
+	level := math.Max(energyDB, float64(v.cfg.MinEnergyDB)-float64(v.cfg.SNRThresholdDB))
+	if level < v.floor {
+		v.floor += (level - v.floor) * energyVADFallDecay
+	} else {
+		frameSec := float64(n) / float64(chunkRate(n))
+		v.floor += (level - v.floor) * (1 - math.Exp(-frameSec/float64(v.cfg.NoiseFloorRiseSeconds)))
+	}
+	return float32(prob), nil
+}
+
+// flatness returns the spectral flatness (geometric / arithmetic mean of the
+// power spectrum) of frame over the speech band.
+func (v *EnergyVAD) flatness(frame []float32) float64 {
+	n := len(frame)
+	plan, window := v.plan, v.window
+	if n == NarrowbandChunkSize {
+		plan, window = v.plan8k, v.window8k
+	}
+	re, im := v.re[:n], v.im[:n]
+	for i, x := range frame {
+		re[i] = float64(x) * window[i]
+		im[i] = 0
+	}
+	plan.Transform(re, im)
+	binHz := float64(chunkRate(n)) / float64(n)
+	lo, hi := int(energyVADLowHz/binHz), int(energyVADHighHz/binHz)
+	var logSum, sum float64
+	for k := lo; k <= hi; k++ {
+		p := re[k]*re[k] + im[k]*im[k] + energyVADEps
+		logSum += math.Log(p)
+		sum += p
+	}
+	bins := float64(hi - lo + 1)
+	return math.Exp(logSum/bins) / (sum / bins)
+}
+
+// Reset restores the initial noise floor.
+func (v *EnergyVAD) Reset() {
+	v.floor = float64(v.cfg.InitialNoiseFloorDB)
+}
+
+// MarshalBinary encodes the noise floor for Engine.Snapshot.
+func (v *EnergyVAD) MarshalBinary() ([]byte, error) {
+	return binary.LittleEndian.AppendUint64(nil, math.Float64bits(v.floor)), nil
+}
+
+// UnmarshalBinary restores a noise floor written by MarshalBinary.
+func (v *EnergyVAD) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return fmt.Errorf("%w: invalid EnergyVAD state", ErrSnapshot)
+	}
+	v.floor = math.Float64frombits(binary.LittleEndian.Uint64(data))
+	return nil
+}
+
+// Close is a no-op; EnergyVAD holds no external resources.
+func (v *EnergyVAD) Close() error { return nil }
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
@@ -0,0 +1,161 @@
+package smartturn
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func newTestEnergyVAD(t *testing.T) *EnergyVAD {
+	t.Helper()
+	v, err := NewEnergyVAD(DefaultEnergyVADConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// vadProbs scores audio in frames of n samples.
+func vadProbs(t *testing.T, v *EnergyVAD, audio []float32, n int) []float32 {
+	t.Helper()
+	var out []float32
+	for ; len(audio) >= n; audio = audio[n:] {
+		p, err := v.SpeechProbability(audio[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func mixed(a, b []float32) []float32 {
+	out := make([]float32, len(a))
+	for i := range a {
+		out[i] = a[i] + b[i]
+	}
+	return out
+}
+
+// TestEnergyVADSpeechOverNoise separates a voiced signal from the noise it is
+// mixed with, starting from a floor at the noise level, at 16 kHz with
+// 512-sample frames and at 8 kHz with 256.
+func TestEnergyVADSpeechOverNoise(t *testing.T) {
+	cfg := DefaultEnergyVADConfig()
+	cfg.InitialNoiseFloorDB = -45 // uniform noise of amplitude 0.01
+	for _, f := range []struct{ rate, n int }{
+		{RequiredSampleRate, RequiredChunkSize},
+		{NarrowbandSampleRate, NarrowbandChunkSize},
+	} {
+		v, err := NewEnergyVAD(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bg := noise(5, 4*f.rate, 0.01)
+		for i, p := range vadProbs(t, v, bg[:2*f.rate], f.n) {
+			if p >= 0.5 {
+				t.Fatalf("%d Hz: noise frame %d has probability %v", f.rate, i, p)
+			}
+		}
+		speech := mixed(bg[2*f.rate:], voice(f.rate, 2*f.rate, 0.2))
+		for i, p := range vadProbs(t, v, speech, f.n) {
+			if p <= 0.9 {
+				t.Fatalf("%d Hz: speech frame %d has probability %v", f.rate, i, p)
+			}
+		}
+	}
+}
+
+// TestEnergyVADNoiseFloor steps the background level: the floor follows a
+// quieter background within a few frames and a louder one slowly, with
+// NoiseFloorRiseSeconds, at the same pace for 16 kHz and 8 kHz frames.
+func TestEnergyVADNoiseFloor(t *testing.T) {
+	// Quiet noise stays above the floor's lower bound, MinEnergyDB-SNRThresholdDB.
+	const quiet, loud = 0.002, 0.03
+	level := func(amp float64) float64 { return 10 * math.Log10(amp*amp/3) } // uniform noise power
+	cfg := DefaultEnergyVADConfig()
+	cfg.InitialNoiseFloorDB = -40
+	for _, f := range []struct{ rate, n int }{
+		{RequiredSampleRate, RequiredChunkSize},
+		{NarrowbandSampleRate, NarrowbandChunkSize},
+	} {
+		v, err := NewEnergyVAD(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vadProbs(t, v, noise(6, 20*f.n, quiet), f.n)
+		if d := math.Abs(v.floor - level(quiet)); d > 1 {
+			t.Errorf("%d Hz: floor %.1f dB 20 frames into quiet noise, want %.1f", f.rate, v.floor, level(quiet))
+		}
+
+		from := v.floor
+		vadProbs(t, v, noise(7, f.rate, loud), f.n)
+		// One second of an 8 s time constant covers 1-e^(-1/8), about 12%, of the step.
+		if risen := (v.floor - from) / (level(loud) - from); risen < 0.05 || risen > 0.2 {
+			t.Errorf("%d Hz: floor covered %.0f%% of the step up in 1 s, want about 12%%", f.rate, 100*risen)
+		}
+		vadProbs(t, v, noise(8, 40*f.rate, loud), f.n)
+		if d := math.Abs(v.floor - level(loud)); d > 1 {
+			t.Errorf("%d Hz: floor %.1f dB 41 s after the step up, want %.1f", f.rate, v.floor, level(loud))
+		}
+
+		vadProbs(t, v, noise(9, 20*f.n, quiet), f.n)
+		if d := math.Abs(v.floor - level(quiet)); d > 1 {
+			t.Errorf("%d Hz: floor %.1f dB 20 frames after the step down, want %.1f", f.rate, v.floor, level(quiet))
+		}
+	}
+}
+
+func TestEnergyVADChunkSize(t *testing.T) {
+	v := newTestEnergyVAD(t)
+	for _, n := range []int{0, 255, 480, 1024} {
+		if _, err := v.SpeechProbability(make([]float32, n)); !errors.Is(err, ErrChunkSize) {
+			t.Errorf("%d-sample frame: err = %v, want ErrChunkSize", n, err)
+		}
+	}
+}
+
+// TestEnergyVADState checks that Reset restores a fresh detector and that
+// MarshalBinary carries the floor into another one.
+func TestEnergyVADState(t *testing.T) {
+	audio := mixed(noise(10, 3*RequiredSampleRate, 0.01), voice(RequiredSampleRate, 3*RequiredSampleRate, 0.05))
+	warm, rest := audio[:2*RequiredSampleRate], audio[2*RequiredSampleRate:]
+
+	v := newTestEnergyVAD(t)
+	vadProbs(t, v, warm, RequiredChunkSize)
+	state, err := v.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := newTestEnergyVAD(t)
+	if err := restored.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	want := vadProbs(t, v, rest, RequiredChunkSize)
+	if got := vadProbs(t, restored, rest, RequiredChunkSize); !equalProbs(got, want) {
+		t.Errorf("restored detector scores %v, want %v", got, want)
+	}
+
+	v.Reset()
+	if got, want := vadProbs(t, v, audio, RequiredChunkSize), vadProbs(t, newTestEnergyVAD(t), audio, RequiredChunkSize); !equalProbs(got, want) {
+		t.Errorf("after Reset the detector scores %v, want %v", got, want)
+	}
+
+	for _, data := range [][]byte{nil, state[:7], append(state, 0)} {
+		if err := restored.UnmarshalBinary(data); !errors.Is(err, ErrSnapshot) {
+			t.Errorf("UnmarshalBinary of %d bytes = %v, want ErrSnapshot", len(data), err)
+		}
+	}
+}
+
+func equalProbs(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
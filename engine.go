@@ -1,11 +1,8 @@
 package smartturn
 
 import (
-	"errors"
-	"os"
 	"sync"
-
-	ort "github.com/yalue/onnxruntime_go"
+	"time"
 )
 
 // segmentEmitPool reuses buffers for OnSegmentReady to avoid per-emit allocations.
@@ -19,22 +16,47 @@ var segmentEmitPool = sync.Pool{
 // after brew install onnxruntime, set to the path to libonnxruntime.dylib).
 const EnvONNXRuntimeLib = "ONNXRUNTIME_SHARED_LIBRARY_PATH"
 
-var (
-	ErrChunkSize = errors.New("chunk must be exactly 512 samples")
-)
-
 // Engine is the main SDK entry. It is single-threaded and not goroutine-safe;
-// the caller must serialize PushPCM and lifecycle methods.
+// the caller must serialize PushPCM and lifecycle methods, or wrap the engine
+// in a Worker.
 type Engine struct {
 	cfg       Config
 	cb        Callbacks
-	vad       *sileroVAD
+	vad       VoiceActivityDetector
 	segmenter *segmenter
-	smartTurn *smartTurn
+	predictor TurnPredictor
 
 	listening bool
 	closed    bool
 
+	// mixer reduces interleaved multi-channel input to mono; nil for mono input.
+	mixer *downmixer
+
+	// rate is the VAD and segmentation rate: 16 kHz, or 8 kHz with
+	// NarrowbandChunkSize.
+	rate int
+
+	// resampler converts Config.SampleRate input to rate; nil when the input
+	// is already at rate. resampled is its reusable output buffer.
+	resampler *resampler
+	resampled []float32
+
+	// upsampler converts 8 kHz segments to 16 kHz for the TurnPredictor; nil
+	// when rate is 16 kHz.
+	upsampler *resampler
+
+	// pending holds samples pushed via PushSamples that do not yet fill a chunk.
+	pending    []float32
+	pendingLen int
+
+	// Stream time, in samples at rate from the first chunk (or the last Reset).
+	streamPos     int64 // end of the last processed chunk
+	segmentStart  int64 // first sample of the current segment, including pre-roll
+	lastVoicedEnd int64 // end of the last chunk VAD classified as speech
+
+	lastVADProb  float32 // most recent VAD probability, for State
+	lastTurnProb float32 // most recent turn probability, for State
+
 	segmentEmitSamples  int // target samples per OnSegmentReady slice
 	segmentEmittedSoFar int // how many samples of the current segment have been emitted
 
@@ -44,63 +66,152 @@ type Engine struct {
 	turnPending             bool
 	turnPendingSilenceChunks int
 	turnTimeoutChunks        int // ceil(TurnTimeoutMs / chunkMs)
+
+	// turnAudio is the audio of a pending turn before its current segment, at
+	// rate, keeping at most turnContext samples. A resumed turn is scored with
+	// it, so predictions of one turn overlap.
+	turnAudio []float32
+
+	events chan Event // created by Events; nil until then
+
+	// Async Smart-Turn (Config.AsyncTurnPrediction). While predicting, the
+	// turn is pending and the prediction for generation predictGen is in
+	// flight; bumping predictGen invalidates it.
+	turnWorker       *turnWorker
+	predicting       bool
+	predictGen       uint64
+	predictStart     int64 // segment bounds of the in-flight prediction
+	predictEnd       int64
+	predictVoicedEnd int64 // lastVoicedEnd when the segment ended
+
+	ownModels *Models // loaded by New when Config.Models is nil; closed by Close
 }
 
 // New creates an engine from config and callbacks. It validates config, loads ONNX
-// models, and creates sessions. The ONNX Runtime shared library path is taken from
-// Config.ONNXRuntimeLibPath if set, else from EnvONNXRuntimeLib. Caller is responsible
-// for resolving the lib path (e.g. via a utility or env).
+// models, and creates sessions; failures are reported as *StageError. The ONNX
+// Runtime shared library path is taken from Config.ONNXRuntimeLibPath if set,
+// else from EnvONNXRuntimeLib. Caller is responsible for resolving the lib path
+// (e.g. via a utility or env).
 //
 // ONNX Runtime is initialized once per process. Calling New again reuses the existing
 // environment so multiple engines (e.g. microphone and system audio) can coexist.
+// Each engine loads its own model sessions unless Config.Models shares them.
 func New(cfg Config, cb Callbacks) (*Engine, error) {
 	if err := validateConfig(cfg); err != nil {
 		return nil, err
 	}
-	if path := cfg.ONNXRuntimeLibPath; path != "" {
-		ort.SetSharedLibraryPath(path)
-	} else if path := os.Getenv(EnvONNXRuntimeLib); path != "" {
-		ort.SetSharedLibraryPath(path)
-	}
-	// ONNX Runtime allows one environment per process. Multiple Engine instances
-	// (e.g. mic + system audio) must share it — skip re-init if already done.
-	if !ort.IsInitialized() {
-		if err := ort.InitializeEnvironment(); err != nil {
+	// Models are loaded privately unless shared through cfg.Models. ONNX
+	// Runtime is not needed at all when both models are replaced.
+	models := cfg.Models
+	var owned *Models
+	if models == nil && (cfg.VAD == nil || cfg.TurnPredictor == nil) {
+		mc := ModelsConfig{ONNXRuntimeLibPath: cfg.ONNXRuntimeLibPath}
+		if cfg.VAD == nil {
+			mc.SileroVADModelPath = cfg.SileroVADModelPath
+		}
+		if cfg.TurnPredictor == nil {
+			mc.SmartTurnModelPath = cfg.SmartTurnModelPath
+		}
+		m, err := LoadModels(mc)
+		if err != nil {
 			return nil, err
 		}
+		models, owned = m, m
 	}
-	e := &Engine{cfg: cfg, cb: cb}
-	vad, err := newSileroVAD(cfg.SileroVADModelPath)
-	if err != nil {
-		return nil, err
+	e := &Engine{cfg: cfg, cb: cb, rate: chunkRate(cfg.ChunkSize), pending: make([]float32, cfg.ChunkSize), ownModels: owned}
+	vad := cfg.VAD
+	if vad == nil {
+		sv, err := newSileroVAD(models.vad, sileroFrameFor(cfg.ChunkSize))
+		if err != nil {
+			e.closeModels()
+			return nil, &StageError{Stage: StageLoad, Err: err}
+		}
+		vad = sv
 	}
-	st, err := newSmartTurn(cfg.SmartTurnModelPath)
-	if err != nil {
-		_ = vad.destroy()
-		return nil, err
+	tp := cfg.TurnPredictor
+	if tp == nil {
+		st, err := newSmartTurn(models.turn)
+		if err != nil {
+			_ = vad.Close()
+			e.closeModels()
+			return nil, &StageError{Stage: StageLoad, Err: err}
+		}
+		tp = st
+	}
+	if cfg.Channels > 1 {
+		e.mixer = newDownmixer(cfg.Channels, cfg.ChannelPolicy, cfg.Channel, cfg.SampleRate)
+	}
+	if cfg.SampleRate != e.rate {
+		e.resampler = newResampler(cfg.SampleRate, e.rate)
 	}
-	seg := newSegmenter(cfg.SampleRate, cfg.ChunkSize, cfg.VadPreSpeechMs, cfg.VadStopMs, cfg.TurnMaxDurationSeconds)
+	if e.rate != RequiredSampleRate {
+		e.upsampler = newResampler(e.rate, RequiredSampleRate)
+	}
+	seg := newSegmenter(e.rate, cfg.ChunkSize, cfg.VadPreSpeechMs, cfg.VadStopMs, cfg.TurnMaxDurationSeconds)
 	e.vad = vad
 	e.segmenter = seg
-	e.smartTurn = st
+	e.predictor = tp
+	if cfg.AsyncTurnPrediction {
+		e.turnWorker = newTurnWorker(tp)
+	}
+	e.deriveTimings()
+	return e, nil
+}
+
+// deriveTimings recomputes the chunk counts derived from e.cfg.
+func (e *Engine) deriveTimings() {
+	cfg := e.cfg
 	// Derive how many samples correspond to one emit interval.
 	if cfg.TurnSegmentEmitMs > 0 {
-		e.segmentEmitSamples = int(float64(cfg.TurnSegmentEmitMs) * float64(cfg.SampleRate) / 1000.0)
+		e.segmentEmitSamples = int(float64(cfg.TurnSegmentEmitMs) * float64(e.rate) / 1000.0)
 		if e.segmentEmitSamples <= 0 {
 			e.segmentEmitSamples = cfg.ChunkSize
 		}
 	} else {
 		e.segmentEmitSamples = cfg.ChunkSize
 	}
-	// 512 samples @ 16 kHz = 32 ms per chunk
-	chunkMs := 32
+	// 512 samples @ 16 kHz = 256 samples @ 8 kHz = 32 ms per chunk
+	chunkMs := cfg.ChunkSize * 1000 / e.rate
 	if cfg.TurnTimeoutMs > 0 {
 		e.turnTimeoutChunks = (cfg.TurnTimeoutMs + chunkMs - 1) / chunkMs
 		if e.turnTimeoutChunks <= 0 {
 			e.turnTimeoutChunks = 1
 		}
 	}
-	return e, nil
+}
+
+// Config returns the engine's current configuration, e.g. as the base for
+// UpdateConfig.
+func (e *Engine) Config() Config {
+	return e.cfg
+}
+
+// UpdateConfig validates cfg and applies it from the next chunk without
+// reloading models. Tunable fields are VadThreshold, VadPreSpeechMs, VadStopMs,
+// TurnMaxDurationSeconds, TurnSegmentEmitMs, TurnThreshold, TurnTimeoutMs and,
+// with ChannelSelect, Channel. Fields that need a new engine (SampleRate,
+// ChunkSize, Channels, ChannelPolicy, AsyncTurnPrediction, model and runtime
+// paths, and whether VAD and TurnPredictor are set) must be unchanged; the
+// engine keeps the detector and predictor it was created with.
+//
+// An open segment stays open: a shorter VadStopMs or TurnMaxDurationSeconds
+// ends it on the next chunk if already exceeded, and a shorter VadPreSpeechMs
+// keeps only the newest pre-roll.
+func (e *Engine) UpdateConfig(cfg Config) error {
+	if e.closed {
+		return ErrClosed
+	}
+	if err := checkConfigUpdate(e.cfg, cfg); err != nil {
+		return err
+	}
+	cfg.VAD, cfg.TurnPredictor = e.cfg.VAD, e.cfg.TurnPredictor
+	e.cfg = cfg
+	e.deriveTimings()
+	e.segmenter.reconfigure(newConfigSegment(e.rate, cfg.ChunkSize, cfg.VadPreSpeechMs, cfg.VadStopMs, cfg.TurnMaxDurationSeconds))
+	if e.mixer != nil && cfg.ChannelPolicy == ChannelSelect {
+		e.mixer.channel = cfg.Channel
+	}
+	return nil
 }
 
 // Start starts listening. Invokes OnListeningStarted callback.
@@ -109,9 +220,7 @@ func (e *Engine) Start() {
 		return
 	}
 	e.listening = true
-	if e.cb.OnListeningStarted != nil {
-		e.cb.OnListeningStarted()
-	}
+	e.emit(ListeningStarted{At: e.streamTime(e.streamPos)})
 }
 
 // Stop stops listening. Invokes OnListeningStopped callback.
@@ -120,79 +229,189 @@ func (e *Engine) Stop() {
 		return
 	}
 	e.listening = false
-	if e.cb.OnListeningStopped != nil {
-		e.cb.OnListeningStopped()
-	}
+	e.emit(ListeningStopped{At: e.streamTime(e.streamPos)})
 }
 
-// PushPCM processes one chunk of 512 float32 samples (mono, 16 kHz).
-// Returns ErrChunkSize if len(chunk) != 512. Callbacks are invoked synchronously.
+// PushPCM processes one chunk of Config.ChunkSize float32 frames at
+// Config.SampleRate; with Config.Channels > 1 the chunk holds ChunkSize
+// interleaved frames. Returns ErrChunkSize if len(chunk) != ChunkSize*Channels.
+// Callbacks are invoked synchronously. If samples from an earlier PushSamples
+// call are still buffered, the chunk is appended after them so stream order is
+// preserved. At rates other than the VAD rate the chunk is resampled and
+// rebuffered exactly like PushSamples.
 func (e *Engine) PushPCM(chunk []float32) error {
 	if e.closed {
-		return errors.New("engine is closed")
+		return ErrClosed
 	}
-	if len(chunk) != RequiredChunkSize {
+	if len(chunk) != e.cfg.ChunkSize*e.cfg.Channels {
 		return ErrChunkSize
 	}
+	return e.PushSamples(chunk)
+}
+
+// PushSamples processes any number of float32 samples at Config.SampleRate,
+// interleaved when Config.Channels > 1 (a trailing partial frame is kept for
+// the next call). Multi-channel input is downmixed per Config.ChannelPolicy and
+// input at other rates is resampled to the VAD rate (16 kHz, or 8 kHz with
+// NarrowbandChunkSize). Samples are rebuffered internally and the engine runs
+// once per full ChunkSize chunk;
+// a trailing partial chunk is kept until the next call or Flush. Callbacks are
+// invoked synchronously. If processing a chunk fails, the error is returned and
+// the rest of samples is discarded.
+func (e *Engine) PushSamples(samples []float32) error {
+	if e.closed {
+		return ErrClosed
+	}
+	if e.mixer != nil {
+		samples = e.mixer.process(samples)
+	}
+	if e.resampler != nil {
+		e.resampled = e.resampler.process(e.resampled[:0], samples)
+		samples = e.resampled
+	}
+	return e.pushChunks(samples)
+}
+
+// pushChunks rebuffers samples at rate into chunks and processes each full one.
+func (e *Engine) pushChunks(samples []float32) error {
+	size := len(e.pending)
+	// Fast path: process whole chunks straight from the caller's slice when
+	// nothing is buffered. processChunk does not retain the chunk.
+	for e.pendingLen == 0 && len(samples) >= size {
+		if err := e.processChunk(samples[:size]); err != nil {
+			return err
+		}
+		samples = samples[size:]
+	}
+	for len(samples) > 0 {
+		n := copy(e.pending[e.pendingLen:], samples)
+		e.pendingLen += n
+		samples = samples[n:]
+		if e.pendingLen < size {
+			break
+		}
+		e.pendingLen = 0
+		if err := e.processChunk(e.pending); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Buffered returns the number of samples at the VAD rate held back by PushSamples that
+// do not yet form a full chunk.
+func (e *Engine) Buffered() int {
+	return e.pendingLen
+}
+
+// Flush processes any buffered partial chunk, padded with silence to ChunkSize
+// samples, after draining the resampler, which then restarts so audio pushed
+// afterwards is resampled as a new stream. An incomplete interleaved frame
+// cannot be downmixed and is discarded. With Config.AsyncTurnPrediction it also
+// waits for an in-flight prediction and delivers its events. Call it at the end
+// of a stream so trailing audio is not lost.
+func (e *Engine) Flush() error {
+	if e.closed {
+		return ErrClosed
+	}
+	if e.mixer != nil {
+		e.mixer.partialLen = 0
+	}
+	if e.resampler != nil {
+		e.resampled = e.resampler.flush(e.resampled[:0])
+		e.resampler.reset()
+		if err := e.pushChunks(e.resampled); err != nil {
+			return err
+		}
+	}
+	if e.pendingLen > 0 {
+		clear(e.pending[e.pendingLen:])
+		e.pendingLen = 0
+		if err := e.processChunk(e.pending); err != nil {
+			return err
+		}
+	}
+	e.awaitPrediction()
+	return nil
+}
+
+// processChunk runs VAD, segmentation and Smart-Turn on one full chunk.
+func (e *Engine) processChunk(chunk []float32) error {
+	chunkStart := e.streamPos
+	e.streamPos += int64(len(chunk))
 	if !e.listening {
 		return nil
 	}
 
-	prob, err := e.vad.speechProb(chunk)
+	prob, err := e.vad.SpeechProbability(chunk)
 	if err != nil {
-		if e.cb.OnError != nil {
-			e.cb.OnError(err)
-		}
+		err = stageError(StageVAD, err)
+		e.emitError(err)
 		return err
 	}
+	e.lastVADProb = prob
 	isSpeech := prob > e.cfg.VadThreshold
 
-	// If we're in a pending turn (skipped OnSpeechEnd), count silence and maybe timeout.
+	// Deliver an async prediction that finished since the last chunk; a
+	// stale outcome is discarded.
+	if e.turnWorker != nil {
+		select {
+		case out := <-e.turnWorker.outcome:
+			e.resolvePrediction(out)
+		default:
+		}
+	}
+
+	// If we're in a pending turn (skipped OnSpeechEnd), count silence and maybe
+	// timeout. The timeout waits for an in-flight prediction to resolve first.
 	if e.turnPending {
 		if isSpeech {
 			e.turnPendingSilenceChunks = 0
 		} else {
 			e.turnPendingSilenceChunks++
-			if e.turnPendingSilenceChunks >= e.turnTimeoutChunks {
-				e.turnPending = false
-				e.turnPendingSilenceChunks = 0
-				if e.cb.OnSpeechEnd != nil {
-					e.cb.OnSpeechEnd()
-				}
+			if !e.predicting && e.turnPendingSilenceChunks >= e.turnTimeoutChunks {
+				e.endTurn(e.lastVoicedEnd)
 			}
 		}
 	}
+	if isSpeech {
+		e.lastVoicedEnd = e.streamPos
+	}
 
 	res := e.segmenter.processChunk(isSpeech, chunk)
 	// Reset emitted counter on a new segment.
 	if res.Started {
 		e.segmentEmittedSoFar = 0
+		e.segmentStart = e.streamPos - int64(len(res.Segment))
+		// The new segment repeats its pre-roll, already in turnAudio.
+		e.dropTurnAudio(len(res.Segment) - len(chunk))
+		// Speech resumed before the async prediction arrived: the turn goes on.
+		if e.predicting {
+			e.cancelPrediction()
+		}
 	}
 	// Do not fire OnSpeechStart again if we're still in a turn that didn't complete.
-	if res.Started && !e.turnPending && e.cb.OnSpeechStart != nil {
-		e.cb.OnSpeechStart()
+	if res.Started && !e.turnPending {
+		e.emit(SpeechStart{
+			At:        e.streamTime(chunkStart),
+			PreRollAt: e.streamTime(e.segmentStart),
+		})
 	}
 	if e.cb.OnChunk != nil {
-		e.cb.OnChunk(chunk)
+		e.cb.OnChunk(Chunk{Audio: chunk, Start: e.streamTime(chunkStart)})
+	}
+	if e.turnPending && len(res.Segment) == 0 {
+		e.appendTurnAudio(chunk)
 	}
 
 	// While speech is active, res.Segment holds the full accumulated segment so far.
-	if len(res.Segment) > 0 && e.segmentEmitSamples > 0 && e.cb.OnSegmentReady != nil {
+	if len(res.Segment) > 0 && e.segmentEmitSamples > 0 && e.wantsSegments() {
 		total := len(res.Segment)
 		// Emit fixed-size slices as we cross each interval boundary.
 		for total-e.segmentEmittedSoFar >= e.segmentEmitSamples {
 			start := e.segmentEmittedSoFar
 			end := start + e.segmentEmitSamples
-			n := end - start
-			slice := segmentEmitPool.Get().([]float32)
-			if cap(slice) < n {
-				slice = make([]float32, n)
-			} else {
-				slice = slice[:n]
-			}
-			copy(slice, res.Segment[start:end])
-			e.cb.OnSegmentReady(slice)
-			segmentEmitPool.Put(slice)
+			e.emitSegment(res.Segment, start, end)
 			e.segmentEmittedSoFar = end
 		}
 	}
@@ -201,75 +420,221 @@ func (e *Engine) PushPCM(chunk []float32) error {
 		shouldEndSpeech := true
 
 		// Emit any remaining tail for this segment before Smart-Turn or speech end callback.
-		if len(res.Segment) > e.segmentEmittedSoFar && e.cb.OnSegmentReady != nil {
-			start := e.segmentEmittedSoFar
-			end := len(res.Segment)
-			n := end - start
-			slice := segmentEmitPool.Get().([]float32)
-			if cap(slice) < n {
-				slice = make([]float32, n)
-			} else {
-				slice = slice[:n]
-			}
-			copy(slice, res.Segment[start:end])
-			e.cb.OnSegmentReady(slice)
-			segmentEmitPool.Put(slice)
+		if len(res.Segment) > e.segmentEmittedSoFar && e.wantsSegments() {
+			e.emitSegment(res.Segment, e.segmentEmittedSoFar, len(res.Segment))
 		}
 
 		// Best-effort Smart-Turn inference on the full segment. If the model
 		// fails or reports a low probability, we skip OnSpeechEnd so the host
-		// can treat this as an incomplete turn.
-		if res.EndedBySilence && e.smartTurn != nil {
-			if r, err := e.smartTurn.run(res.Segment); err != nil {
-				if e.cb.OnError != nil {
-					e.cb.OnError(err)
-				}
-				shouldEndSpeech = false
-			} else if e.cb.OnTurnPrediction != nil {
-				e.cb.OnTurnPrediction(r.Complete, r.Probability)
-				if r.Probability < e.cfg.TurnThreshold {
-					shouldEndSpeech = false
-				}
-			}
+		// can treat this as an incomplete turn. In async mode the turn stays
+		// pending until resolvePrediction sees the result.
+		if res.EndedBySilence && e.turnWorker != nil {
+			seg, start := e.turnSegment(res.Segment)
+			e.predicting = true
+			e.predictGen++
+			e.predictStart, e.predictEnd = start, e.streamPos
+			e.predictVoicedEnd = e.lastVoicedEnd
+			e.turnWorker.submit(e.predictGen, seg)
+			shouldEndSpeech = false
+		} else if res.EndedBySilence && e.predictor != nil {
+			seg, start := e.turnSegment(res.Segment)
+			r, err := e.predictor.PredictTurn(seg)
+			shouldEndSpeech = e.reportPrediction(r, err, start, e.streamPos)
 		}
 
 		if shouldEndSpeech {
-			e.turnPending = false
-			e.turnPendingSilenceChunks = 0
-			if e.cb.OnSpeechEnd != nil {
-				e.cb.OnSpeechEnd()
-			}
+			e.endTurn(e.lastVoicedEnd)
 		} else {
 			e.turnPending = true
 			e.turnPendingSilenceChunks = 0
+			e.appendTurnAudio(res.Segment)
 		}
 		e.segmentEmittedSoFar = 0
 	}
 	return nil
 }
 
-// Reset clears VAD state, segment state, and turn-pending state. Sessions are not closed.
+// reportPrediction emits a Smart-Turn outcome for the segment [start, end) and
+// reports whether it completes the turn.
+func (e *Engine) reportPrediction(r TurnResult, err error, start, end int64) bool {
+	if err != nil {
+		e.emitError(stageError(StageTurn, err))
+		return false
+	}
+	e.lastTurnProb = r.Probability
+	e.emit(TurnPrediction{
+		Complete:    r.Complete,
+		Probability: r.Probability,
+		Start:       e.streamTime(start),
+		End:         e.streamTime(end),
+		Metadata:    r.Metadata,
+	})
+	return r.Probability >= e.cfg.TurnThreshold
+}
+
+// turnSegment wraps the segment that just ended for the TurnPredictor,
+// preceded by the pending turn's earlier audio up to turnContext samples in
+// all, and returns the stream position where its audio starts. The segmenter
+// drops its reference on end, and the audio is otherwise built in a new slice,
+// so the async worker may own it.
+func (e *Engine) turnSegment(segment []float32) (TurnSegment, int64) {
+	audio := segment
+	if keep := min(len(e.turnAudio), max(0, e.turnContext()-len(segment))); keep > 0 {
+		audio = make([]float32, 0, keep+len(segment))
+		audio = append(audio, e.turnAudio[len(e.turnAudio)-keep:]...)
+		audio = append(audio, segment...)
+	}
+	start := e.streamPos - int64(len(audio))
+	if e.upsampler != nil {
+		audio = e.upsampler.resampleAll(audio)
+	}
+	return TurnSegment{
+		Audio: audio,
+		Start: e.streamTime(start),
+		End:   e.streamTime(e.streamPos),
+	}, start
+}
+
+// turnContext is how much audio, in samples at rate, a TurnSegment holds at
+// most when it includes earlier audio of the turn: Smart-Turn's 8 s window,
+// plus a chunk so that at 8 kHz the upsampling edge falls outside it.
+func (e *Engine) turnContext() int {
+	return turnWindowSeconds*e.rate + e.cfg.ChunkSize
+}
+
+// appendTurnAudio adds audio to turnAudio, discarding what falls outside
+// turnContext once twice that much has accumulated.
+func (e *Engine) appendTurnAudio(audio []float32) {
+	e.turnAudio = append(e.turnAudio, audio...)
+	if n, keep := len(e.turnAudio), e.turnContext(); n >= 2*keep {
+		e.turnAudio = e.turnAudio[:copy(e.turnAudio, e.turnAudio[n-keep:])]
+	}
+}
+
+// dropTurnAudio removes up to n samples from the end of turnAudio.
+func (e *Engine) dropTurnAudio(n int) {
+	e.turnAudio = e.turnAudio[:max(0, len(e.turnAudio)-n)]
+}
+
+// resolvePrediction applies an async outcome unless it has been invalidated.
+// A turn that is not complete stays pending; if the silence timeout already
+// elapsed while waiting, it ends now.
+func (e *Engine) resolvePrediction(out turnOutcome) {
+	if !e.predicting || out.gen != e.predictGen {
+		return
+	}
+	e.predicting = false
+	if e.reportPrediction(out.res, out.err, e.predictStart, e.predictEnd) ||
+		e.turnPendingSilenceChunks >= e.turnTimeoutChunks {
+		e.endTurn(e.predictVoicedEnd)
+	}
+}
+
+// awaitPrediction blocks until the in-flight async prediction is resolved.
+func (e *Engine) awaitPrediction() {
+	for e.predicting {
+		e.resolvePrediction(<-e.turnWorker.outcome)
+	}
+}
+
+// cancelPrediction invalidates the in-flight async prediction, if any: the
+// worker skips its job or drops its outcome.
+func (e *Engine) cancelPrediction() {
+	e.predicting = false
+	e.predictGen++
+	if e.turnWorker != nil {
+		e.turnWorker.invalidate(e.predictGen)
+	}
+}
+
+// streamTime converts a sample offset at rate to stream time.
+func (e *Engine) streamTime(samples int64) time.Duration {
+	return sampleTime(samples, e.rate)
+}
+
+// endTurn clears the pending turn and emits SpeechEnd at voicedEnd.
+func (e *Engine) endTurn(voicedEnd int64) {
+	e.turnPending = false
+	e.turnPendingSilenceChunks = 0
+	e.turnAudio = e.turnAudio[:0]
+	e.emit(SpeechEnd{At: e.streamTime(voicedEnd)})
+}
+
+// emitSegment emits segment[start:end] as SegmentReady in a pooled buffer.
+func (e *Engine) emitSegment(segment []float32, start, end int) {
+	n := end - start
+	slice := segmentEmitPool.Get().([]float32)
+	if cap(slice) < n {
+		slice = make([]float32, n)
+	} else {
+		slice = slice[:n]
+	}
+	copy(slice, segment[start:end])
+	e.emit(SegmentReady{
+		Audio: slice,
+		Start: e.streamTime(e.segmentStart + int64(start)),
+		End:   e.streamTime(e.segmentStart + int64(end)),
+	})
+	segmentEmitPool.Put(slice)
+}
+
+// Reset clears VAD state, segment state, buffered samples, and turn-pending state,
+// and rewinds stream time to zero. Sessions are not closed.
 func (e *Engine) Reset() {
 	if e.closed {
 		return
 	}
-	e.vad.resetState()
+	e.vad.Reset()
 	e.segmenter.reset()
+	e.pendingLen = 0
+	if e.mixer != nil {
+		e.mixer.reset()
+	}
+	if e.resampler != nil {
+		e.resampler.reset()
+	}
 	e.turnPending = false
 	e.turnPendingSilenceChunks = 0
+	e.turnAudio = e.turnAudio[:0]
+	e.cancelPrediction()
+	e.segmentEmittedSoFar = 0
+	e.lastVADProb = 0
+	e.lastTurnProb = 0
+	e.streamPos = 0
+	e.segmentStart = 0
+	e.lastVoicedEnd = 0
 }
 
-// Close releases ONNX sessions and resources. The engine must not be used after Close.
+// Close releases ONNX sessions and resources and closes the Events channel.
+// The engine must not be used after Close.
 func (e *Engine) Close() {
 	if e.closed {
 		return
 	}
 	e.closed = true
 	e.listening = false
-	if err := e.vad.destroy(); err != nil && e.cb.OnError != nil {
-		e.cb.OnError(err)
+	if e.turnWorker != nil {
+		e.turnWorker.stop()
+	}
+	if err := e.vad.Close(); err != nil {
+		e.emitError(stageError(StageClose, err))
+	}
+	if err := e.predictor.Close(); err != nil {
+		e.emitError(stageError(StageClose, err))
+	}
+	e.closeModels()
+	if e.events != nil {
+		close(e.events)
+	}
+}
+
+// closeModels closes models New loaded for this engine alone.
+func (e *Engine) closeModels() {
+	if e.ownModels == nil {
+		return
 	}
-	if err := e.smartTurn.destroy(); err != nil && e.cb.OnError != nil {
-		e.cb.OnError(err)
+	if err := e.ownModels.Close(); err != nil {
+		e.emitError(err)
 	}
+	e.ownModels = nil
 }
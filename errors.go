@@ -0,0 +1,105 @@
+package smartturn
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrClosed is returned by Engine methods called after Close.
+	ErrClosed = errors.New("engine is closed")
+	// ErrChunkSize is returned when a chunk does not have the required length.
+	ErrChunkSize = errors.New("chunk must be exactly ChunkSize samples")
+	// ErrModelsClosed is returned by inference on a Models handle that has been closed.
+	ErrModelsClosed = errors.New("models are closed")
+	// ErrInvalidSegment is returned when a segment is too short to extract features from.
+	ErrInvalidSegment = errors.New("invalid segment for Smart-Turn")
+
+	// ErrInvalidConfig matches (errors.Is) every StageError from config validation.
+	ErrInvalidConfig = errors.New("invalid config")
+	// ErrInference matches (errors.Is) every StageError from VAD, mel extraction
+	// or turn prediction. These are per-chunk or per-segment failures; the
+	// engine keeps running and later audio may succeed.
+	ErrInference = errors.New("inference failed")
+)
+
+// Stage identifies where in the SDK a StageError occurred.
+type Stage int
+
+const (
+	StageConfig  Stage = iota + 1 // Config validation; StageError.Field names the field
+	StageRuntime                  // ONNX Runtime initialization
+	StageLoad                     // model session creation
+	StageVAD                      // voice activity detection
+	StageMel                      // mel feature extraction
+	StageTurn                     // turn prediction
+	StageClose                    // session teardown
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageConfig:
+		return "config"
+	case StageRuntime:
+		return "onnxruntime"
+	case StageLoad:
+		return "load"
+	case StageVAD:
+		return "vad"
+	case StageMel:
+		return "mel"
+	case StageTurn:
+		return "turn"
+	case StageClose:
+		return "close"
+	}
+	return fmt.Sprintf("Stage(%d)", int(s))
+}
+
+// StageError is the error type returned by New and reported through OnError
+// and Error events. It records the failing stage and wraps the underlying
+// cause, so errors.Is reaches both the cause and ErrInvalidConfig or
+// ErrInference, and errors.As extracts the stage:
+//
+//	var se *smartturn.StageError
+//	if errors.As(err, &se) && se.Stage == smartturn.StageVAD { ... }
+type StageError struct {
+	Stage Stage
+	Field string // offending Config field for StageConfig; empty otherwise
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return e.Stage.String() + ": " + e.Err.Error()
+}
+
+func (e *StageError) Unwrap() error { return e.Err }
+
+// Is reports whether target is the category sentinel for e.Stage.
+func (e *StageError) Is(target error) bool {
+	switch target {
+	case ErrInvalidConfig:
+		return e.Stage == StageConfig
+	case ErrInference:
+		return e.Stage == StageVAD || e.Stage == StageMel || e.Stage == StageTurn
+	}
+	return false
+}
+
+// configError reports an invalid Config field.
+func configError(field, msg string) error {
+	return &StageError{Stage: StageConfig, Field: field, Err: errors.New(msg)}
+}
+
+// stageError wraps err in a StageError for stage unless it already carries one,
+// so errors from custom detectors and predictors are classified too.
+func stageError(stage Stage, err error) error {
+	if err == nil {
+		return nil
+	}
+	var se *StageError
+	if errors.As(err, &se) {
+		return err
+	}
+	return &StageError{Stage: stage, Err: err}
+}
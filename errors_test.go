@@ -0,0 +1,42 @@
+package smartturn
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestConstructorConfigErrors checks that constructors outside New report
+// invalid settings as StageConfig errors naming the field.
+func TestConstructorConfigErrors(t *testing.T) {
+	vadCfg := DefaultEnergyVADConfig()
+	vadCfg.InitialNoiseFloorDB = 0
+	_, vadErr := NewEnergyVAD(vadCfg)
+
+	c := testConfig(RequiredSampleRate, RequiredChunkSize)
+	c.VAD, c.TurnPredictor = indexVAD{}, &recordPredictor{}
+	e, err := New(c, Callbacks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	_, queueErr := NewWorker(e, WorkerConfig{})
+	_, policyErr := NewWorker(e, WorkerConfig{QueueSize: 1, Overflow: OverflowPolicy(99)})
+	_, formatErr := NewWorker(e, WorkerConfig{QueueSize: 1, Format: SampleFormat{Encoding: 99}})
+	_, pcmErr := NewPCMWriter(e, SampleFormat{})
+
+	for _, tc := range []struct {
+		err   error
+		field string
+	}{
+		{vadErr, "InitialNoiseFloorDB"},
+		{queueErr, "QueueSize"},
+		{policyErr, "Overflow"},
+		{formatErr, "Format"},
+		{pcmErr, "Format"},
+	} {
+		var se *StageError
+		if !errors.Is(tc.err, ErrInvalidConfig) || !errors.As(tc.err, &se) || se.Field != tc.field {
+			t.Errorf("%v: want an ErrInvalidConfig StageError for %s", tc.err, tc.field)
+		}
+	}
+}
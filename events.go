@@ -0,0 +1,106 @@
+package smartturn
+
+import (
+	"slices"
+	"time"
+)
+
+// eventBufferSize is the capacity of the channel returned by Engine.Events.
+const eventBufferSize = 256
+
+// Event is a typed engine event, delivered through Engine.Events as an
+// alternative to Callbacks. The concrete types are ListeningStarted,
+// ListeningStopped, SpeechStart, SpeechEnd, SegmentReady, TurnPrediction,
+// Error and Overflow; use a type switch to handle them.
+type Event interface {
+	isEvent()
+}
+
+// Error is the Event form of OnError.
+type Error struct {
+	Err error
+	At  time.Duration // stream time when the error occurred
+}
+
+func (ListeningStarted) isEvent() {}
+func (ListeningStopped) isEvent() {}
+func (SpeechStart) isEvent()      {}
+func (SpeechEnd) isEvent()        {}
+func (SegmentReady) isEvent()     {}
+func (TurnPrediction) isEvent()   {}
+func (Error) isEvent()            {}
+
+// Events returns a channel that receives every event except Chunk, in the same
+// order the callbacks fire. Callbacks still run; the channel is an additional
+// sink. It is created on the first call and closed by Close.
+//
+// The engine sends synchronously from PushPCM/PushSamples and blocks when the
+// buffer is full, so receive from a goroutine other than the one pushing audio.
+// Unlike OnSegmentReady, SegmentReady.Audio received from the channel is a
+// private copy the receiver owns and may retain.
+func (e *Engine) Events() <-chan Event {
+	if e.events == nil {
+		e.events = make(chan Event, eventBufferSize)
+		if e.closed {
+			close(e.events)
+		}
+	}
+	return e.events
+}
+
+// emit dispatches ev to its callback and, if Events was called, to the channel.
+func (e *Engine) emit(ev Event) {
+	switch ev := ev.(type) {
+	case ListeningStarted:
+		if e.cb.OnListeningStarted != nil {
+			e.cb.OnListeningStarted(ev)
+		}
+	case ListeningStopped:
+		if e.cb.OnListeningStopped != nil {
+			e.cb.OnListeningStopped(ev)
+		}
+	case SpeechStart:
+		if e.cb.OnSpeechStart != nil {
+			e.cb.OnSpeechStart(ev)
+		}
+	case SpeechEnd:
+		if e.cb.OnSpeechEnd != nil {
+			e.cb.OnSpeechEnd(ev)
+		}
+	case SegmentReady:
+		if e.cb.OnSegmentReady != nil {
+			e.cb.OnSegmentReady(ev)
+		}
+		if e.events != nil {
+			// The pooled buffer is reused after emit returns; the receiver gets its own.
+			ev.Audio = slices.Clone(ev.Audio)
+			e.events <- ev
+		}
+		return
+	case TurnPrediction:
+		if e.cb.OnTurnPrediction != nil {
+			e.cb.OnTurnPrediction(ev)
+		}
+	case Error:
+		if e.cb.OnError != nil {
+			e.cb.OnError(ev.Err)
+		}
+	case Overflow:
+		if e.cb.OnOverflow != nil {
+			e.cb.OnOverflow(ev)
+		}
+	}
+	if e.events != nil {
+		e.events <- ev
+	}
+}
+
+// emitError reports err through OnError and the event channel.
+func (e *Engine) emitError(err error) {
+	e.emit(Error{Err: err, At: e.streamTime(e.streamPos)})
+}
+
+// wantsSegments reports whether anyone consumes SegmentReady events.
+func (e *Engine) wantsSegments() bool {
+	return e.cb.OnSegmentReady != nil || e.events != nil
+}
@@ -0,0 +1,129 @@
+package smartturn
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// signVAD reports speech for frames whose first sample is positive and fails
+// on negative ones.
+type signVAD struct{}
+
+func (signVAD) SpeechProbability(frame []float32) (float32, error) {
+	switch {
+	case frame[0] < 0:
+		return 0, errors.New("bad frame")
+	case frame[0] > 0:
+		return 1, nil
+	}
+	return 0, nil
+}
+func (signVAD) Reset()       {}
+func (signVAD) Close() error { return nil }
+
+// alternatePredictor leaves every other turn pending.
+type alternatePredictor struct{ n int }
+
+func (p *alternatePredictor) PredictTurn(TurnSegment) (TurnResult, error) {
+	p.n++
+	if p.n%2 == 1 {
+		return TurnResult{Probability: 0.2}, nil
+	}
+	return TurnResult{Complete: true, Probability: 0.9}, nil
+}
+func (*alternatePredictor) Close() error { return nil }
+
+// TestEventsMatchCallbacks receives from Events while audio is pushed: the
+// channel must carry the callbacks' events in the same order, with segment
+// audio intact, and be closed by Close.
+func TestEventsMatchCallbacks(t *testing.T) {
+	cfg := testConfig(RequiredSampleRate, RequiredChunkSize)
+	cfg.VAD = signVAD{}
+	cfg.TurnPredictor = &alternatePredictor{}
+	var want []Event
+	e, err := New(cfg, recordEvents(&want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := e.Events()
+	received := make(chan []Event)
+	go func() {
+		var got []Event
+		for ev := range ch {
+			got = append(got, ev)
+		}
+		received <- got
+	}()
+
+	push := func(n int, level float32) {
+		chunk := make([]float32, RequiredChunkSize)
+		for i := range chunk {
+			chunk[i] = level
+		}
+		for i := 0; i < n; i++ {
+			_ = e.PushSamples(chunk) // the VAD error is reported as an event
+		}
+	}
+	e.Start()
+	push(5, 0.5)
+	push(3, 0) // pending turn
+	push(4, 0.5)
+	push(3, 0) // complete turn
+	push(1, -1)
+	e.Stop()
+	push(2, 0.5)
+	e.Start()
+	push(3, 0.5)
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	e.Close()
+
+	var got []Event
+	select {
+	case got = <-received:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Events channel not closed by Close")
+	}
+	for i, ev := range got {
+		if ev, ok := ev.(Error); ok {
+			got[i] = Error{Err: ev.Err} // OnError does not receive At
+		}
+	}
+	for _, kind := range []func([]Event) bool{
+		hasEvent[ListeningStopped], hasEvent[SegmentReady], hasEvent[TurnPrediction], hasEvent[SpeechEnd], hasEvent[Error],
+	} {
+		if !kind(want) {
+			t.Fatalf("stream lacks an event kind: %v", want)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Events delivered\n%v\ncallbacks saw\n%v", got, want)
+	}
+
+	if _, ok := <-e.Events(); ok {
+		t.Error("Events after Close returned an open channel")
+	}
+}
+
+// TestEventsAfterClose returns a closed channel when Events is first called
+// after Close.
+func TestEventsAfterClose(t *testing.T) {
+	cfg := testConfig(RequiredSampleRate, RequiredChunkSize)
+	cfg.VAD, cfg.TurnPredictor = signVAD{}, &alternatePredictor{}
+	e, err := New(cfg, Callbacks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Close()
+	select {
+	case _, ok := <-e.Events():
+		if ok {
+			t.Error("received an event after Close")
+		}
+	case <-time.After(10 * time.Second):
+		t.Error("Events after Close is not closed")
+	}
+}
@@ -13,10 +13,7 @@ import (
 	"github.com/youpy/go-wav"
 )
 
-const (
-	chunkSize   = 512
-	segmentRate = 16000
-)
+const segmentRate = 16000
 
 var (
 	defaultWAV = "data/test.wav"
@@ -59,9 +56,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	samples, sampleRate, channels, err := loadWAV(wavPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load WAV: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The engine downmixes stereo and resamples to 16 kHz internally; segments
+	// are emitted as 16 kHz mono.
 	cfg := smartturn.Config{
-		SampleRate:             16000,
+		SampleRate:             sampleRate,
 		ChunkSize:              512,
+		Channels:               channels,
+		ChannelPolicy:          smartturn.ChannelAverage,
 		VadThreshold:           0.75,
 		VadPreSpeechMs:         200,
 		VadStopMs:              800,
@@ -75,21 +82,23 @@ func main() {
 	}
 	var segmentNum int
 	cb := smartturn.Callbacks{
-		OnListeningStarted: func() { fmt.Println("[event] listening started") },
-		OnListeningStopped: func() { fmt.Println("[event] listening stopped") },
-		OnSpeechStart:      func() { fmt.Println("[event] speech start") },
-		OnSpeechEnd:        func() { fmt.Println("[event] speech end") },
-		OnTurnPrediction: func(complete bool, prob float32) {
-			fmt.Printf("[event] turn prediction complete=%v prob=%.3f\n", complete, prob)
+		OnListeningStarted: func(smartturn.ListeningStarted) { fmt.Println("[event] listening started") },
+		OnListeningStopped: func(smartturn.ListeningStopped) { fmt.Println("[event] listening stopped") },
+		OnSpeechStart: func(ev smartturn.SpeechStart) {
+			fmt.Printf("[event] speech start at %v (pre-roll from %v)\n", ev.At, ev.PreRollAt)
+		},
+		OnSpeechEnd: func(ev smartturn.SpeechEnd) { fmt.Printf("[event] speech end at %v\n", ev.At) },
+		OnTurnPrediction: func(ev smartturn.TurnPrediction) {
+			fmt.Printf("[event] turn prediction complete=%v prob=%.3f [%v, %v)\n", ev.Complete, ev.Probability, ev.Start, ev.End)
 		},
-		OnSegmentReady: func(seg []float32) {
+		OnSegmentReady: func(ev smartturn.SegmentReady) {
 			segmentNum++
 			name := filepath.Join(outDir, fmt.Sprintf("segment_%03d.wav", segmentNum))
-			if err := saveSegmentWAV(name, seg, segmentRate); err != nil {
+			if err := saveSegmentWAV(name, ev.Audio, segmentRate); err != nil {
 				fmt.Fprintf(os.Stderr, "save %s: %v\n", name, err)
 				return
 			}
-			fmt.Printf("[event] segment ready (%d samples) -> %s\n", len(seg), name)
+			fmt.Printf("[event] segment ready [%v, %v) (%d samples) -> %s\n", ev.Start, ev.End, len(ev.Audio), name)
 		},
 		OnError: func(err error) { fmt.Printf("[error] %v\n", err) },
 	}
@@ -101,44 +110,37 @@ func main() {
 	}
 	defer engine.Close()
 
-	samples, sampleRate, err := loadWAV(wavPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "load WAV: %v\n", err)
-		os.Exit(1)
-	}
-	if sampleRate != 16000 {
-		fmt.Fprintf(os.Stderr, "warning: WAV is %d Hz; resampling not implemented, using as-is\n", sampleRate)
-	}
-
 	engine.Start()
 	defer engine.Stop()
 
-	for i := 0; i+chunkSize <= len(samples); i += chunkSize {
-		chunk := samples[i : i+chunkSize]
-		if err := engine.PushPCM(chunk); err != nil {
-			fmt.Fprintf(os.Stderr, "PushPCM: %v\n", err)
-			os.Exit(1)
-		}
+	if err := engine.PushSamples(samples); err != nil {
+		fmt.Fprintf(os.Stderr, "PushSamples: %v\n", err)
+		os.Exit(1)
+	}
+	if err := engine.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Flush: %v\n", err)
+		os.Exit(1)
 	}
 	fmt.Println("done")
 }
 
-func loadWAV(path string) (samples []float32, sampleRate int, err error) {
+// loadWAV returns interleaved float32 samples with the file's rate and channel count.
+func loadWAV(path string) (samples []float32, sampleRate, numChannels int, err error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, 0, err
 	}
 	defer func() { _ = f.Close() }()
 
 	wavReader := wav.NewReader(f)
 	format, err := wavReader.Format()
 	if err != nil {
-		return nil, 0, fmt.Errorf("WAV format: %w", err)
+		return nil, 0, 0, fmt.Errorf("WAV format: %w", err)
 	}
 	sampleRate = int(format.SampleRate)
-	numChannels := int(format.NumChannels)
+	numChannels = int(format.NumChannels)
 	if numChannels < 1 || numChannels > 2 {
-		return nil, 0, fmt.Errorf("WAV: only mono or stereo supported, got %d channels", numChannels)
+		return nil, 0, 0, fmt.Errorf("WAV: only mono or stereo supported, got %d channels", numChannels)
 	}
 
 	var out []float32
@@ -148,19 +150,15 @@ func loadWAV(path string) (samples []float32, sampleRate int, err error) {
 			break
 		}
 		if err != nil {
-			return nil, 0, fmt.Errorf("reading WAV samples: %w", err)
+			return nil, 0, 0, fmt.Errorf("reading WAV samples: %w", err)
 		}
 		for _, s := range readSamples {
-			var v float64
-			if numChannels == 1 {
-				v = wavReader.FloatValue(s, 0)
-			} else {
-				v = (wavReader.FloatValue(s, 0) + wavReader.FloatValue(s, 1)) / 2
+			for c := 0; c < numChannels; c++ {
+				out = append(out, float32(wavReader.FloatValue(s, uint(c))))
 			}
-			out = append(out, float32(v))
 		}
 	}
-	return out, sampleRate, nil
+	return out, sampleRate, numChannels, nil
 }
 
 func saveSegmentWAV(path string, samples []float32, sampleRate int) error {
@@ -5,11 +5,9 @@
 package main
 
 import (
-	"encoding/binary"
+	"context"
 	"fmt"
-	"math"
 	"os"
-	"sync"
 
 	"github.com/cortexswarm/smart-turn-go"
 	"github.com/cortexswarm/smart-turn-go/examples/utility/resolver"
@@ -56,6 +54,7 @@ func main() {
 	cfg := smartturn.Config{
 		SampleRate:             sampleRate,
 		ChunkSize:              chunkSize,
+		Channels:               1,
 		VadThreshold:           0.75,
 		VadPreSpeechMs:         200,
 		VadStopMs:              800,
@@ -68,13 +67,18 @@ func main() {
 		ONNXRuntimeLibPath:     onnxLibPath,
 	}
 	cb := smartturn.Callbacks{
-		OnListeningStarted: func() { fmt.Println("[callback] listening started") },
-		OnListeningStopped: func() { fmt.Println("[callback] listening stopped") },
-		OnSpeechStart:      func() { fmt.Println("[callback] speech start") },
-		OnSpeechEnd:        func() { fmt.Println("[callback] speech end") },
-		OnSegmentReady:     func(seg []float32) { fmt.Printf("[callback] segment ready (%d samples)\n", len(seg)) },
-		OnTurnPrediction:   func(complete bool, prob float32) { fmt.Printf("[callback] turn prediction complete=%v prob=%.3f\n", complete, prob) },
+		OnListeningStarted: func(smartturn.ListeningStarted) { fmt.Println("[callback] listening started") },
+		OnListeningStopped: func(smartturn.ListeningStopped) { fmt.Println("[callback] listening stopped") },
+		OnSpeechStart:      func(ev smartturn.SpeechStart) { fmt.Printf("[callback] speech start at %v\n", ev.At) },
+		OnSpeechEnd:        func(ev smartturn.SpeechEnd) { fmt.Printf("[callback] speech end at %v\n", ev.At) },
+		OnSegmentReady: func(ev smartturn.SegmentReady) {
+			fmt.Printf("[callback] segment ready [%v, %v) (%d samples)\n", ev.Start, ev.End, len(ev.Audio))
+		},
+		OnTurnPrediction: func(ev smartturn.TurnPrediction) {
+			fmt.Printf("[callback] turn prediction complete=%v prob=%.3f\n", ev.Complete, ev.Probability)
+		},
 		OnError:            func(err error) { fmt.Printf("[callback] error: %v\n", err) },
+		OnOverflow:         func(ev smartturn.Overflow) { fmt.Printf("[callback] overflow: dropped %d buffers\n", ev.Dropped) },
 	}
 
 	engine, err := smartturn.New(cfg, cb)
@@ -84,18 +88,24 @@ func main() {
 	}
 	defer engine.Close()
 
-	// Chunks of 512 float32 sent from capture callback to engine goroutine
-	chunkCh := make(chan []float32, 64)
-	var wg sync.WaitGroup
-	wg.Add(1)
+	// The worker owns the engine on its own goroutine. The capture callback
+	// writes raw f32le buffers to it; if the engine falls behind, the oldest
+	// queued buffers are dropped and reported through OnOverflow.
+	worker, err := smartturn.NewWorker(engine, smartturn.WorkerConfig{
+		QueueSize: 64,
+		Overflow:  smartturn.OverflowDropOldest,
+		Format:    smartturn.FormatF32LE,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "worker: %v\n", err)
+		os.Exit(1)
+	}
+	done := make(chan struct{})
 	go func() {
-		defer wg.Done()
-		engine.Start()
-		defer engine.Stop()
-		for ch := range chunkCh {
-			_ = engine.PushPCM(ch)
-		}
+		defer close(done)
+		_ = worker.Run(context.Background())
 	}()
+	_ = worker.Start()
 
 	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
 	deviceConfig.Capture.Format = malgo.FormatF32
@@ -103,25 +113,12 @@ func main() {
 	deviceConfig.SampleRate = sampleRate
 	deviceConfig.Alsa.NoMMap = 1
 
-	var buf []float32
 	onRecvFrames := func(_, pSample []byte, framecount uint32) {
 		if framecount == 0 {
 			return
 		}
-		n := int(framecount) * int(deviceConfig.Capture.Channels)
-		for i := 0; i < n; i++ {
-			buf = append(buf, float32FromBytes(pSample[i*4:]))
-		}
-		for len(buf) >= chunkSize {
-			chunk := make([]float32, chunkSize)
-			copy(chunk, buf[:chunkSize])
-			buf = append(buf[:0], buf[chunkSize:]...)
-			select {
-			case chunkCh <- chunk:
-			default:
-				// drop if consumer is slow
-			}
-		}
+		n := int(framecount) * int(deviceConfig.Capture.Channels) * 4
+		_, _ = worker.Write(pSample[:n])
 	}
 
 	device, err := malgo.InitDevice(ctx.Context, deviceConfig, malgo.DeviceCallbacks{Data: onRecvFrames})
@@ -139,10 +136,8 @@ func main() {
 	fmt.Println("Capturing from default microphone. Callbacks will print below. Press Enter to stop...")
 	fmt.Scanln()
 
-	close(chunkCh)
-	wg.Wait()
-}
-
-func float32FromBytes(b []byte) float32 {
-	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	_ = worker.Flush()
+	_ = worker.Stop()
+	worker.Close()
+	<-done
 }
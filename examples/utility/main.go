@@ -37,6 +37,7 @@ func main() {
 	cfg := smartturn.Config{
 		SampleRate:              16000,
 		ChunkSize:               512,
+		Channels:                1,
 		VadThreshold:            0.75,
 		VadPreSpeechMs:          200,
 		VadStopMs:               800,
@@ -49,11 +50,11 @@ func main() {
 		ONNXRuntimeLibPath:      onnxLibPath,
 	}
 	cb := smartturn.Callbacks{
-		OnListeningStarted: func() { fmt.Println("[event] listening started") },
-		OnListeningStopped: func() { fmt.Println("[event] listening stopped") },
-		OnSpeechStart:      func() { fmt.Println("[event] speech start") },
-		OnSpeechEnd:        func() { fmt.Println("[event] speech end") },
-		OnTurnPrediction:   func(complete bool, prob float32) { fmt.Printf("[event] turn complete=%v prob=%.3f\n", complete, prob) },
+		OnListeningStarted: func(smartturn.ListeningStarted) { fmt.Println("[event] listening started") },
+		OnListeningStopped: func(smartturn.ListeningStopped) { fmt.Println("[event] listening stopped") },
+		OnSpeechStart:      func(smartturn.SpeechStart) { fmt.Println("[event] speech start") },
+		OnSpeechEnd:        func(smartturn.SpeechEnd) { fmt.Println("[event] speech end") },
+		OnTurnPrediction:   func(ev smartturn.TurnPrediction) { fmt.Printf("[event] turn complete=%v prob=%.3f\n", ev.Complete, ev.Probability) },
 		OnError:            func(err error) { fmt.Printf("[error] %v\n", err) },
 	}
 
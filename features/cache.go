@@ -0,0 +1,180 @@
+package features
+
+// melColumn is the cached analysis of one frame of raw audio; see
+// tables.column.
+type melColumn struct {
+	pos    int64  // absolute stream position of the frame's first sample
+	used   uint64 // generation of the last ExtractAt that read it
+	x0, x1 complex128
+	sums   []float64 // NMels
+}
+
+// frameCache holds the melColumns of frames lying wholly inside the audio of
+// recent ExtractAt calls, keyed by absolute position, and the raw audio of the
+// last call to check that a column's samples are unchanged before reuse.
+type frameCache struct {
+	columns map[int64]*melColumn
+	free    []*melColumn
+	limit   int // columns kept; successive windows often sit on different hop grids
+	gen     uint64
+	nfft    int
+
+	hist      []float32 // raw audio of the last call
+	histStart int64     // its absolute position
+
+	xr, xi []float64 // spectrum scratch, NFFT/2+1
+
+	stats CacheStats
+}
+
+// CacheStats counts the frames ExtractAt analyzed since New.
+type CacheStats struct {
+	Computed uint64 // frames inside the audio that were not cached
+	Reused   uint64 // frames taken from the cache
+}
+
+// cacheGrids is how many hop grids' worth of columns ExtractAt keeps. Segments
+// that grow by 512-sample chunks cycle through 5 grids at a 160-sample hop.
+const cacheGrids = 5
+
+func newFrameCache(e *Extractor) *frameCache {
+	return &frameCache{
+		columns: make(map[int64]*melColumn),
+		limit:   cacheGrids * e.frames,
+		nfft:    e.cfg.NFFT,
+		hist:    make([]float32, 0, e.windowLen()),
+		xr:      make([]float64, e.cfg.NFFT/2+1),
+		xi:      make([]float64, e.cfg.NFFT/2+1),
+	}
+}
+
+// ExtractAt is Extract for audio that ends at absolute stream position end
+// (audio[len(audio)-1] is sample end-1), as when a turn predictor scores a
+// growing segment again and again. Frames lying wholly inside audio seen by
+// earlier calls at the same positions are reused rather than recomputed;
+// normalization and Compress still apply over the whole window, so the
+// result equals Extract's up to float rounding. A position that does not
+// match earlier calls only costs cache hits, since reuse also requires the
+// samples to be unchanged. ResetCache drops the cache.
+func (e *Extractor) ExtractAt(audio []float32, end int64, mel []float32) error {
+	if len(audio) == 0 {
+		return ErrNoAudio
+	}
+	if len(mel) != e.Size() {
+		return ErrMelSize
+	}
+	if e.cache == nil {
+		e.cache = newFrameCache(e)
+	}
+	c := e.cache
+	start := end - int64(len(audio))
+	fitted, offset := e.fit(audio)
+	if e.cfg.Padding == PadLeft {
+		start += int64(len(audio) - len(fitted))
+	}
+	audio = fitted
+	mean, scale := 0.0, 1.0
+	if e.cfg.Normalize {
+		mean, scale = meanScale(audio)
+	}
+	e.fillPadded(audio, offset, mean, scale)
+	c.gen++
+	c.invalidate(audio, start)
+
+	hop, pad, nfft := e.cfg.HopLength, e.cfg.NFFT/2, e.cfg.NFFT
+	windowStart := start - int64(offset)
+	for t := 0; t < e.frames; t++ {
+		pos := windowStart + int64(t*hop-pad)
+		i := int(pos - start)
+		if i < 0 || i+nfft > len(audio) {
+			// Touches zero or reflect padding: depends on the window, not cached.
+			e.tables.logMel(e.padded[t*hop:], e.frame, e.re, e.im, e.power, mel[t:], e.frames)
+			continue
+		}
+		col := c.columns[pos]
+		if col == nil {
+			col = c.alloc(e.cfg.NMels)
+			col.pos = pos
+			e.tables.column(audio[i:], e.frame, e.re, e.im, c.xr, c.xi, e.power, col)
+			c.columns[pos] = col
+			c.stats.Computed++
+		} else {
+			c.stats.Reused++
+		}
+		col.used = c.gen
+		e.tables.columnLogMel(col, mean, scale, mel[t:], e.frames)
+	}
+	c.trim()
+	Compress(mel)
+	return nil
+}
+
+// CacheStats reports how many frames ExtractAt computed and reused. Frames that
+// touch padding are computed on every call and not counted.
+func (e *Extractor) CacheStats() CacheStats {
+	if e.cache == nil {
+		return CacheStats{}
+	}
+	return e.cache.stats
+}
+
+// ResetCache drops the frames cached by ExtractAt, e.g. when a new stream
+// starts its positions at zero again.
+func (e *Extractor) ResetCache() {
+	if e.cache == nil {
+		return
+	}
+	for pos, col := range e.cache.columns {
+		e.cache.release(pos, col)
+	}
+	e.cache.hist = e.cache.hist[:0]
+}
+
+// invalidate drops columns whose samples are not in the longest run of audio
+// (at absolute position start) that matches the last call's audio from the
+// start of their overlap, then records audio as the history.
+func (c *frameCache) invalidate(audio []float32, start int64) {
+	histEnd := c.histStart + int64(len(c.hist))
+	lo := max(start, c.histStart)
+	hi := min(start+int64(len(audio)), histEnd)
+	for p := lo; p < hi; p++ {
+		if audio[p-start] != c.hist[p-c.histStart] {
+			hi = p
+			break
+		}
+	}
+	for pos, col := range c.columns {
+		if pos < lo || pos+int64(c.nfft) > hi {
+			c.release(pos, col)
+		}
+	}
+	c.hist = append(c.hist[:0], audio...)
+	c.histStart = start
+}
+
+// trim evicts columns the last call did not read once there are more than
+// limit.
+func (c *frameCache) trim() {
+	if len(c.columns) <= c.limit {
+		return
+	}
+	for pos, col := range c.columns {
+		if col.used != c.gen {
+			c.release(pos, col)
+		}
+	}
+}
+
+func (c *frameCache) alloc(nMels int) *melColumn {
+	if n := len(c.free); n > 0 {
+		col := c.free[n-1]
+		c.free = c.free[:n-1]
+		return col
+	}
+	return &melColumn{sums: make([]float64, nMels)}
+}
+
+func (c *frameCache) release(pos int64, col *melColumn) {
+	delete(c.columns, pos)
+	c.free = append(c.free, col)
+}
@@ -0,0 +1,23 @@
+package features
+
+// dot returns Σ w[i]·p[i] over len(w); p may be longer. Lanes i%4 accumulate
+// separately, for instruction-level parallelism, and are added as
+// (s0+s2)+(s1+s3) before the tail. The float64 conversions keep the compiler
+// from fusing the multiply and add, as it may on arm64, so results do not
+// depend on the platform.
+func dot(w, p []float64) float64 {
+	p = p[:len(w)]
+	n := len(w) &^ 3
+	var s0, s1, s2, s3 float64
+	for i := 0; i < n; i += 4 {
+		s0 += float64(w[i] * p[i])
+		s1 += float64(w[i+1] * p[i+1])
+		s2 += float64(w[i+2] * p[i+2])
+		s3 += float64(w[i+3] * p[i+3])
+	}
+	s := (s0 + s2) + (s1 + s3)
+	for i := n; i < len(w); i++ {
+		s += float64(w[i] * p[i])
+	}
+	return s
+}
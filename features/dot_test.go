@@ -0,0 +1,129 @@
+package features
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestDotFilters checks dot over the Smart-Turn filters against the dense
+// filterbank product. Filters narrower than four bins only take the scalar
+// tail, so the test requires both kinds.
+func TestDotFilters(t *testing.T) {
+	tb := tablesFor(16000, 400, 80)
+	dense := melFilterbank(tb.nMels, tb.nBins, 16000, 0, 8000)
+	rng := rand.New(rand.NewSource(2))
+	power := make([]float64, tb.nBins)
+	for i := range power {
+		power[i] = rng.ExpFloat64()
+	}
+	var narrow, wide int
+	for m, f := range tb.filters {
+		if len(f.weights) < 4 {
+			narrow++
+		} else {
+			wide++
+		}
+		var want float64
+		for k, w := range dense[m*tb.nBins : (m+1)*tb.nBins] {
+			want += w * power[k]
+		}
+		if got := dot(f.weights, power[f.start:]); math.Abs(got-want) > 1e-12*want {
+			t.Errorf("filter %d: dot = %v, dense product = %v", m, got, want)
+		}
+	}
+	if narrow == 0 || wide == 0 {
+		t.Fatalf("%d narrow and %d wide filters, want both", narrow, wide)
+	}
+}
+
+// denseExtract is Extract with the per-bin filterbank loop the sparse
+// filters replaced: every filter multiplies all nBins power bins.
+func denseExtract(x *Extractor, bank []float64, audio, mel []float32) {
+	t := x.tables
+	audio, offset := x.fit(audio)
+	mean, scale := meanScale(audio)
+	x.fillPadded(audio, offset, mean, scale)
+	for f := 0; f < x.frames; f++ {
+		samples := x.padded[f*x.cfg.HopLength:]
+		for i, w := range t.window {
+			x.frame[i] = float64(samples[i]) * w
+		}
+		t.fft.Power(x.frame, x.re, x.im, x.power)
+		for m := 0; m < t.nMels; m++ {
+			var v float64
+			for k, w := range bank[m*t.nBins : (m+1)*t.nBins] {
+				v += w * x.power[k]
+			}
+			if v < 1e-10 {
+				v = 1e-10
+			}
+			mel[m*x.frames+f] = float32(math.Log10(v))
+		}
+	}
+	Compress(mel)
+}
+
+func benchmarkExtract(b *testing.B, dense bool) {
+	audio := noise(3, 8*16000, 0.3)
+	x := newSmartTurn(b)
+	bank := melFilterbank(x.tables.nMels, x.tables.nBins, 16000, 0, 8000)
+	mel := make([]float32, x.Size())
+	b.ReportAllocs()
+	for b.Loop() {
+		if dense {
+			denseExtract(x, bank, audio, mel)
+		} else if err := x.Extract(audio, mel); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkExtract compares the sparse filters with the dense per-bin loop
+// they replaced.
+func BenchmarkExtract(b *testing.B) {
+	b.Run("sparse", func(b *testing.B) { benchmarkExtract(b, false) })
+	b.Run("dense", func(b *testing.B) { benchmarkExtract(b, true) })
+}
+
+// TestSparseFasterThanDense guards the sparse filterbank speedup.
+func TestSparseFasterThanDense(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	x := newSmartTurn(t)
+	bank := melFilterbank(x.tables.nMels, x.tables.nBins, 16000, 0, 8000)
+	audio := noise(3, 8*16000, 0.3)
+	want, got := make([]float32, x.Size()), make([]float32, x.Size())
+	denseExtract(x, bank, audio, want)
+	if err := x.Extract(audio, got); err != nil {
+		t.Fatal(err)
+	}
+	if d := maxDiff(got, want); d > 1e-5 {
+		t.Fatalf("sparse and dense Extract differ by %g", d)
+	}
+	sparse := testing.Benchmark(func(b *testing.B) { benchmarkExtract(b, false) })
+	dense := testing.Benchmark(func(b *testing.B) { benchmarkExtract(b, true) })
+	if sparse.NsPerOp() >= dense.NsPerOp() {
+		t.Errorf("sparse Extract %v/op is not faster than dense %v/op",
+			time.Duration(sparse.NsPerOp()), time.Duration(dense.NsPerOp()))
+	}
+}
+
+// BenchmarkDot times the Smart-Turn filters' products for one frame.
+func BenchmarkDot(b *testing.B) {
+	tb := tablesFor(16000, 400, 80)
+	rng := rand.New(rand.NewSource(4))
+	power := make([]float64, tb.nBins)
+	for i := range power {
+		power[i] = rng.ExpFloat64()
+	}
+	var s float64
+	for b.Loop() {
+		for _, f := range tb.filters {
+			s += dot(f.weights, power[f.start:])
+		}
+	}
+	_ = s
+}
@@ -0,0 +1,249 @@
+// Package features computes Whisper-style log-mel spectrograms, the input
+// features of Smart-Turn and of Whisper-family ASR models. Extractor matches
+// transformers.WhisperFeatureExtractor for a fixed-length window; Stream emits
+// log-mel frames incrementally as audio arrives.
+package features
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/cortexswarm/smart-turn-go/internal/fft"
+)
+
+var (
+	// ErrNoAudio is returned by Extractor.Extract for empty audio.
+	ErrNoAudio = errors.New("features: no audio")
+	// ErrMelSize is returned when the mel output does not hold Size() values.
+	ErrMelSize = errors.New("features: mel length must equal Size()")
+	// ErrInvalidConfig matches (errors.Is) every error returned by New.
+	ErrInvalidConfig = errors.New("features: invalid config")
+)
+
+// Padding selects where a short input is padded with zeros and which end of
+// a long input is kept.
+type Padding int
+
+const (
+	PadRight Padding = iota + 1 // audio first, zeros after; long input keeps its start (Whisper)
+	PadLeft                     // zeros first, audio last; long input keeps its end (Smart-Turn)
+)
+
+// Config describes a log-mel extraction. All fields must be set; SmartTurn and
+// Whisper return the model presets.
+type Config struct {
+	SampleRate int // Hz (e.g. 16000); mel filters span 0 Hz to SampleRate/2
+	NFFT       int // FFT and analysis window size (e.g. 400); even, with NFFT/2 having no prime factors other than 2, 3 and 5
+	HopLength  int // samples between frames (e.g. 160)
+	NMels      int // mel filters (80, or 128 for Whisper large-v3)
+	// WindowSeconds is the fixed input length Extract pads or truncates to
+	// (8 for Smart-Turn, 30 for Whisper). It yields
+	// WindowSeconds*SampleRate/HopLength frames.
+	WindowSeconds float32
+	Padding       Padding
+	// Normalize scales the audio to zero mean and unit variance,
+	// (x-μ)/√(σ²+1e-7), before padding (do_normalize=True).
+	Normalize bool
+}
+
+// SmartTurn returns the feature config Smart-Turn v3 was trained with.
+func SmartTurn() Config {
+	return Config{
+		SampleRate:    16000,
+		NFFT:          400,
+		HopLength:     160,
+		NMels:         80,
+		WindowSeconds: 8,
+		Padding:       PadLeft,
+		Normalize:     true,
+	}
+}
+
+// Whisper returns the Whisper ASR feature config with nMels filters (80, or
+// 128 for large-v3).
+func Whisper(nMels int) Config {
+	return Config{
+		SampleRate:    16000,
+		NFFT:          400,
+		HopLength:     160,
+		NMels:         nMels,
+		WindowSeconds: 30,
+		Padding:       PadRight,
+		Normalize:     false,
+	}
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.SampleRate <= 0:
+		return configError("SampleRate must be > 0")
+	case c.NFFT <= 0 || !fft.SupportsReal(c.NFFT):
+		return configError("NFFT must be even, with NFFT/2 having no prime factors other than 2, 3 and 5")
+	case c.HopLength <= 0:
+		return configError("HopLength must be > 0")
+	case c.NMels <= 0 || c.NMels > c.NFFT/2:
+		return configError("NMels must be in (0, NFFT/2]")
+	case c.WindowSeconds <= 0:
+		return configError("WindowSeconds must be > 0")
+	case c.Padding != PadRight && c.Padding != PadLeft:
+		return configError("Padding must be PadRight or PadLeft")
+	}
+	if c.windowSamples() < c.HopLength || c.windowSamples() <= c.NFFT/2 {
+		return configError("WindowSeconds is shorter than one frame")
+	}
+	return nil
+}
+
+// configError reports an invalid Config.
+func configError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidConfig, msg)
+}
+
+func (c Config) windowSamples() int {
+	return int(math.Round(float64(c.WindowSeconds) * float64(c.SampleRate)))
+}
+
+// Extractor computes fixed-window log-mel features with reusable scratch
+// buffers, so Extract does not allocate. Filter and FFT tables are shared
+// between extractors with the same rates and sizes. Not safe for concurrent
+// use; give each goroutine its own.
+type Extractor struct {
+	cfg    Config
+	tables *tables
+	frames int
+
+	padded []float32 // NFFT/2 + window + NFFT/2
+	frame  []float64 // NFFT
+	re, im []float64 // NFFT/2
+	power  []float64 // NFFT/2+1
+
+	cache *frameCache // created by the first ExtractAt
+}
+
+// New validates cfg and returns an Extractor.
+func New(cfg Config) (*Extractor, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	window := cfg.windowSamples()
+	return &Extractor{
+		cfg:    cfg,
+		tables: tablesFor(cfg.SampleRate, cfg.NFFT, cfg.NMels),
+		frames: window / cfg.HopLength,
+		padded: make([]float32, window+cfg.NFFT/2*2),
+		frame:  make([]float64, cfg.NFFT),
+		re:     make([]float64, cfg.NFFT/2),
+		im:     make([]float64, cfg.NFFT/2),
+		power:  make([]float64, cfg.NFFT/2+1),
+	}, nil
+}
+
+// Config returns the extractor's configuration.
+func (e *Extractor) Config() Config { return e.cfg }
+
+// Frames returns the number of frames per window.
+func (e *Extractor) Frames() int { return e.frames }
+
+// Size returns the feature length, NMels × Frames.
+func (e *Extractor) Size() int { return e.cfg.NMels * e.frames }
+
+// Extract writes the log-mel features of audio to mel (length Size), laid out
+// mel-major: mel[m*Frames()+t] is filter m of frame t. As in
+// WhisperFeatureExtractor, the audio is optionally normalized, padded or
+// truncated to the window, framed by a centered STFT (reflect padding of
+// NFFT/2 per side, periodic Hann window, |X|²) whose last frame is dropped,
+// filtered by Slaney-scale, Slaney-normalized mel filters, and compressed to
+//
+//	log_spec = log10(max(mel, 1e-10))
+//	log_spec = (max(log_spec, log_spec.max()-8) + 4) / 4
+func (e *Extractor) Extract(audio, mel []float32) error {
+	if len(audio) == 0 {
+		return ErrNoAudio
+	}
+	if len(mel) != e.Size() {
+		return ErrMelSize
+	}
+	audio, offset := e.fit(audio)
+	mean, scale := 0.0, 1.0
+	if e.cfg.Normalize {
+		mean, scale = meanScale(audio)
+	}
+	e.fillPadded(audio, offset, mean, scale)
+	for t := 0; t < e.frames; t++ {
+		e.tables.logMel(e.padded[t*e.cfg.HopLength:], e.frame, e.re, e.im, e.power,
+			mel[t:], e.frames)
+	}
+	Compress(mel)
+	return nil
+}
+
+// fit truncates audio to the window, keeping the end for PadLeft and the start
+// for PadRight, and returns it with its offset in the window.
+func (e *Extractor) fit(audio []float32) ([]float32, int) {
+	window := e.windowLen()
+	if len(audio) > window {
+		if e.cfg.Padding == PadLeft {
+			return audio[len(audio)-window:], 0
+		}
+		return audio[:window], 0
+	}
+	if e.cfg.Padding == PadLeft {
+		return audio, window - len(audio)
+	}
+	return audio, 0
+}
+
+func (e *Extractor) windowLen() int { return len(e.padded) - e.cfg.NFFT/2*2 }
+
+// fillPadded writes the normalized audio at offset into a zeroed window in
+// e.padded and reflect-pads NFFT/2 samples at each end.
+func (e *Extractor) fillPadded(audio []float32, offset int, mean, scale float64) {
+	pad, window := e.cfg.NFFT/2, e.windowLen()
+	signal := e.padded[pad : pad+window]
+	clear(signal)
+	for i, v := range audio {
+		signal[offset+i] = float32((float64(v) - mean) * scale)
+	}
+	// numpy "reflect" padding: mirror about the edge samples, excluding them.
+	for i := 1; i <= pad; i++ {
+		e.padded[pad-i] = signal[i]
+		e.padded[pad+window-1+i] = signal[window-1-i]
+	}
+}
+
+// meanScale returns the mean of audio and 1/√(variance+1e-7).
+func meanScale(audio []float32) (mean, scale float64) {
+	n := float64(len(audio))
+	var sum, sumSq float64
+	for _, v := range audio {
+		x := float64(v)
+		sum += x
+		sumSq += x * x
+	}
+	mean = sum / n
+	variance := sumSq/n - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	return mean, 1 / math.Sqrt(variance+1e-7)
+}
+
+// Compress applies Whisper's window-level dynamic range compression to log10
+// mel values in place: values more than 8 below the maximum are raised to it,
+// then everything is mapped by (x+4)/4.
+func Compress(logMel []float32) {
+	maxVal := float32(math.Inf(-1))
+	for _, v := range logMel {
+		if v > maxVal {
+			maxVal = v
+		}
+	}
+	floor := maxVal - 8
+	for i, v := range logMel {
+		if v < floor {
+			v = floor
+		}
+		logMel[i] = (v + 4) / 4
+	}
+}
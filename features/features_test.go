@@ -0,0 +1,255 @@
+package features
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// noise returns n samples of uniform noise in [-amp, amp].
+func noise(seed int64, n int, amp float32) []float32 {
+	rng := rand.New(rand.NewSource(seed))
+	x := make([]float32, n)
+	for i := range x {
+		x[i] = amp * (2*rng.Float32() - 1)
+	}
+	return x
+}
+
+func newSmartTurn(t testing.TB) *Extractor {
+	t.Helper()
+	x, err := New(SmartTurn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+// TestConcurrentExtractors runs extractors sharing filter and FFT tables on
+// separate goroutines; run with -race.
+func TestConcurrentExtractors(t *testing.T) {
+	audio := noise(1, 3*16000, 0.3)
+	ref := newSmartTurn(t)
+	want := make([]float32, ref.Size())
+	if err := ref.Extract(audio, want); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 4)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			x, err := New(SmartTurn())
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			mel := make([]float32, x.Size())
+			for i := 0; i < 5; i++ {
+				if err := x.Extract(audio, mel); err != nil {
+					errs <- err.Error()
+					return
+				}
+				if d := maxDiff(mel, want); d != 0 {
+					errs <- "Extract differs from a sequential run"
+					return
+				}
+				if err := x.ExtractAt(audio, int64(len(audio)), mel); err != nil {
+					errs <- err.Error()
+					return
+				}
+				if d := maxDiff(mel, want); d > 1e-5 {
+					errs <- "ExtractAt differs from a sequential run"
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for msg := range errs {
+		t.Fatal(msg)
+	}
+}
+
+// TestExtractDoesNotAllocate checks Extract and ExtractAt after warmup,
+// including ExtractAt on a segment that grows by one chunk per call.
+func TestExtractDoesNotAllocate(t *testing.T) {
+	audio := noise(2, 10*16000, 0.3)
+	x := newSmartTurn(t)
+	mel := make([]float32, x.Size())
+
+	if n := testing.AllocsPerRun(20, func() { _ = x.Extract(audio[:3*16000], mel) }); n != 0 {
+		t.Errorf("Extract: %v allocs per run", n)
+	}
+	if n := testing.AllocsPerRun(20, func() { _ = x.ExtractAt(audio[:3*16000], 3*16000, mel) }); n != 0 {
+		t.Errorf("ExtractAt, repeated: %v allocs per run", n)
+	}
+	end := 2 * 16000
+	grow := func() {
+		end += 512
+		if end > len(audio) {
+			end = 2 * 16000
+		}
+		_ = x.ExtractAt(audio[:end], int64(end), mel)
+	}
+	// Warm up over three growth cycles, so the column pool and map reach the
+	// working-set size.
+	for i := 0; i < 3*(len(audio)-end)/512; i++ {
+		grow()
+	}
+	if n := testing.AllocsPerRun(100, grow); n != 0 {
+		t.Errorf("ExtractAt, growing: %v allocs per run", n)
+	}
+}
+
+func maxDiff(a, b []float32) float64 {
+	var d float64
+	for i := range a {
+		d = math.Max(d, math.Abs(float64(a[i])-float64(b[i])))
+	}
+	return d
+}
+
+// goldenCases are the signals in testdata, generated by testdata/golden.py
+// from a float64 transcription of WhisperFeatureExtractor, with their lengths
+// in samples.
+var goldenCases = []struct {
+	name string
+	n    int
+}{
+	{"short", 40000},   // 2.5 s, left-padded
+	{"exact", 128000},  // 8 s
+	{"long", 160000},   // 10 s, the last 8 s kept
+	{"silence", 48000}, // 3 s of zeros, left-padded
+}
+
+// goldenTolerance covers float32 arithmetic against the float64 reference.
+const goldenTolerance = 1e-5
+
+// goldenSignal rebuilds golden.py's signal(): exact multiples of 1/16384, so
+// both sides see the same float32 input.
+func goldenSignal(name string, n int) []float32 {
+	x := make([]float32, n)
+	if name == "silence" {
+		return x
+	}
+	state := uint32(12345)
+	for i := range x {
+		state = (state*1103515245 + 12345) & (1<<31 - 1)
+		noise := int(state>>16)%129 - 64
+		saw := (i*3)%73 - 36
+		square := -200
+		if (i/57)%2 == 1 {
+			square = 200
+		}
+		env := 1 + (i/4000)%4
+		x[i] = float32(env*(saw*8+square+noise)) / 16384
+	}
+	return x
+}
+
+func readGolden(t *testing.T, name string, size int) []float32 {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name+".f32.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 4*size {
+		t.Fatalf("%s: %d bytes, want %d", name, len(data), 4*size)
+	}
+	mel := make([]float32, size)
+	for i := range mel {
+		mel[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return mel
+}
+
+func checkGolden(t *testing.T, what string, got, want []float32) {
+	t.Helper()
+	if d := maxDiff(got, want); d > goldenTolerance {
+		t.Errorf("%s: max difference %g from WhisperFeatureExtractor", what, d)
+	}
+}
+
+func TestExtractGolden(t *testing.T) {
+	x := newSmartTurn(t)
+	mel := make([]float32, x.Size())
+	for _, c := range goldenCases {
+		want := readGolden(t, c.name, x.Size())
+		audio := goldenSignal(c.name, c.n)
+		if err := x.Extract(audio, mel); err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, c.name, mel, want)
+	}
+}
+
+// TestExtractAtGolden scores each signal at an arbitrary stream position,
+// first one second short so the full call reuses cached frames.
+func TestExtractAtGolden(t *testing.T) {
+	x := newSmartTurn(t)
+	mel := make([]float32, x.Size())
+	for _, c := range goldenCases {
+		want := readGolden(t, c.name, x.Size())
+		audio := goldenSignal(c.name, c.n)
+		end := int64(1_000_003 + c.n)
+		x.ResetCache()
+		if err := x.ExtractAt(audio[:c.n-16000], end-16000, mel); err != nil {
+			t.Fatal(err)
+		}
+		if err := x.ExtractAt(audio, end, mel); err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, c.name, mel, want)
+	}
+}
+
+// TestStreamGolden streams the window Extract builds (the normalized audio,
+// fitted and padded to 8 s) and applies Compress to the frames.
+func TestStreamGolden(t *testing.T) {
+	x := newSmartTurn(t)
+	s := x.NewStream()
+	frames := x.Frames()
+	for _, c := range goldenCases {
+		want := readGolden(t, c.name, x.Size())
+		audio, offset := x.fit(goldenSignal(c.name, c.n))
+		mean, scale := meanScale(audio)
+		window := make([]float32, x.windowLen())
+		for i, v := range audio {
+			window[offset+i] = float32((float64(v) - mean) * scale)
+		}
+		mel := make([]float32, x.Size())
+		emit := func(frame []float32) {
+			if s.Frames() > frames {
+				t.Fatalf("%s: more than %d frames", c.name, frames)
+			}
+			for m, v := range frame {
+				mel[m*frames+s.Frames()-1] = v
+			}
+		}
+		for i := 0; i < len(window); i += 4000 {
+			s.Write(window[i:min(i+4000, len(window))], emit)
+		}
+		s.Flush(emit)
+		Compress(mel)
+		checkGolden(t, c.name, mel, want)
+	}
+}
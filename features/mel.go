@@ -0,0 +1,216 @@
+package features
+
+import (
+	"math"
+	"sync"
+
+	"github.com/cortexswarm/smart-turn-go/internal/fft"
+)
+
+// tables are the immutable window, filterbank and FFT plan for one
+// (sample rate, NFFT, NMels); they are shared by every Extractor and Stream
+// with those parameters.
+type tables struct {
+	nfft, nMels, nBins int
+	window             []float64   // periodic Hann, nfft
+	filters            []melFilter // nMels
+	fft                *fft.Real
+	w0, w1             complex128 // window spectrum at bins 0 and 1; zero above
+}
+
+type tablesKey struct{ sampleRate, nfft, nMels int }
+
+var (
+	tablesMu    sync.Mutex
+	tablesCache = make(map[tablesKey]*tables)
+)
+
+func tablesFor(sampleRate, nfft, nMels int) *tables {
+	key := tablesKey{sampleRate, nfft, nMels}
+	tablesMu.Lock()
+	defer tablesMu.Unlock()
+	if t := tablesCache[key]; t != nil {
+		return t
+	}
+	nBins := nfft/2 + 1
+	t := &tables{
+		nfft:    nfft,
+		nMels:   nMels,
+		nBins:   nBins,
+		window:  hannWindow(nfft),
+		filters: sparseFilters(melFilterbank(nMels, nBins, float64(sampleRate), 0, float64(sampleRate)/2), nMels, nBins),
+		fft:     fft.NewReal(nfft),
+	}
+	for i, w := range t.window {
+		a := -2 * math.Pi * float64(i) / float64(nfft)
+		t.w0 += complex(w, 0)
+		t.w1 += complex(w*math.Cos(a), w*math.Sin(a))
+	}
+	tablesCache[key] = t
+	return t
+}
+
+// logMel windows the nfft samples at the start of samples, and writes the
+// log10 mel energies, floored at 1e-10, to out[m*stride] for each filter m.
+// frame, re, im and power are scratch.
+func (t *tables) logMel(samples []float32, frame, re, im, power []float64, out []float32, stride int) {
+	if isSilent(samples[:t.nfft]) {
+		for m := 0; m < t.nMels; m++ {
+			out[m*stride] = melFloorLog
+		}
+		return
+	}
+	for i, w := range t.window {
+		frame[i] = float64(samples[i]) * w
+	}
+	t.fft.Power(frame, re, im, power)
+	for m, f := range t.filters {
+		v := dot(f.weights, power[f.start:])
+		if v < 1e-10 {
+			v = 1e-10
+		}
+		out[m*stride] = float32(math.Log10(v))
+	}
+}
+
+// melFloorLog is log10 of the 1e-10 mel floor, the value of a silent frame.
+const melFloorLog = -10
+
+func isSilent(samples []float32) bool {
+	for _, v := range samples {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// column analyses the nfft raw samples at the start of samples into col. The
+// spectra of the raw and the normalized frame, (x-μ)·s, differ only in bins 0
+// and 1, where the periodic Hann window's spectrum is nonzero, so col keeps
+// the mel sums of bins 2 and up and the complex bins 0 and 1 to apply any μ
+// and s later (columnLogMel). frame, re, im, xr, xi and power are scratch.
+func (t *tables) column(samples []float32, frame, re, im, xr, xi, power []float64, col *melColumn) {
+	for i, w := range t.window {
+		frame[i] = float64(samples[i]) * w
+	}
+	t.fft.Spectrum(frame, re, im, xr, xi)
+	col.x0 = complex(xr[0], xi[0])
+	col.x1 = complex(xr[1], xi[1])
+	power[0], power[1] = 0, 0
+	for k := 2; k < t.nBins; k++ {
+		power[k] = xr[k]*xr[k] + xi[k]*xi[k]
+	}
+	for m, f := range t.filters {
+		col.sums[m] = dot(f.weights, power[f.start:])
+	}
+}
+
+// columnLogMel writes the log10 mel energies of the frame analysed in col,
+// normalized by mean and scale, to out[m*stride].
+func (t *tables) columnLogMel(col *melColumn, mean, scale float64, out []float32, stride int) {
+	d0 := col.x0 - complex(mean, 0)*t.w0
+	d1 := col.x1 - complex(mean, 0)*t.w1
+	p0 := real(d0)*real(d0) + imag(d0)*imag(d0)
+	p1 := real(d1)*real(d1) + imag(d1)*imag(d1)
+	s2 := scale * scale
+	for m, f := range t.filters {
+		v := s2 * (col.sums[m] + f.at(0)*p0 + f.at(1)*p1)
+		if v < 1e-10 {
+			v = 1e-10
+		}
+		out[m*stride] = float32(math.Log10(v))
+	}
+}
+
+// melFilter is one triangular mel filter, stored as its nonzero weights,
+// which span only a few of the FFT bins.
+type melFilter struct {
+	start   int       // first bin with a nonzero weight
+	weights []float64 // bins start, start+1, ...
+}
+
+// at returns the filter's weight for bin k.
+func (f melFilter) at(k int) float64 {
+	if k < f.start || k >= f.start+len(f.weights) {
+		return 0
+	}
+	return f.weights[k-f.start]
+}
+
+// sparseFilters converts a dense nMels × nBins filterbank to melFilters.
+func sparseFilters(dense []float64, nMels, nBins int) []melFilter {
+	filters := make([]melFilter, nMels)
+	for m := range filters {
+		row := dense[m*nBins : (m+1)*nBins]
+		lo, hi := 0, len(row)
+		for lo < hi && row[lo] == 0 {
+			lo++
+		}
+		for hi > lo && row[hi-1] == 0 {
+			hi--
+		}
+		filters[m] = melFilter{start: lo, weights: row[lo:hi:hi]}
+	}
+	return filters
+}
+
+// hannWindow returns the periodic Hann window of length n (np.hanning(n+1)[:-1]).
+func hannWindow(n int) []float64 {
+	w := make([]float64, n)
+	for i := 0; i < n; i++ {
+		w[i] = 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n)))
+	}
+	return w
+}
+
+// melFilterbank returns nMels triangular filters over nBins FFT bins, laid out
+// filter-major, as transformers' mel_filter_bank with norm="slaney" and
+// mel_scale="slaney": filter edges are equally spaced in Slaney mel between
+// lowHz and highHz, and each filter is scaled by 2/(right-left) so it has
+// unit area in Hz.
+func melFilterbank(nMels, nBins int, sampleRate, lowHz, highHz float64) []float64 {
+	lowMel := hzToMel(lowHz)
+	highMel := hzToMel(highHz)
+	hzPoints := make([]float64, nMels+2)
+	for i := range hzPoints {
+		hzPoints[i] = melToHz(lowMel + (highMel-lowMel)*float64(i)/float64(nMels+1))
+	}
+	filters := make([]float64, nMels*nBins)
+	for m := 0; m < nMels; m++ {
+		left := hzPoints[m]
+		center := hzPoints[m+1]
+		right := hzPoints[m+2]
+		norm := 2 / (right - left)
+		for k := 0; k < nBins; k++ {
+			f := float64(k) * (sampleRate / 2) / float64(nBins-1)
+			v := math.Min((f-left)/(center-left), (right-f)/(right-center))
+			if v > 0 {
+				filters[m*nBins+k] = v * norm
+			}
+		}
+	}
+	return filters
+}
+
+// Slaney mel scale: linear below 1 kHz (3 mel per 200 Hz), logarithmic above.
+const (
+	slaneyMinLogHz  = 1000.0
+	slaneyMinLogMel = 15.0
+)
+
+var slaneyLogStep = 27 / math.Log(6.4)
+
+func hzToMel(hz float64) float64 {
+	if hz < slaneyMinLogHz {
+		return 3 * hz / 200
+	}
+	return slaneyMinLogMel + math.Log(hz/slaneyMinLogHz)*slaneyLogStep
+}
+
+func melToHz(mel float64) float64 {
+	if mel < slaneyMinLogMel {
+		return 200 * mel / 3
+	}
+	return slaneyMinLogHz * math.Exp((mel-slaneyMinLogMel)/slaneyLogStep)
+}
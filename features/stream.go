@@ -0,0 +1,102 @@
+package features
+
+// Stream computes log-mel frames incrementally as audio arrives, for live
+// ASR and analytics. Frames follow the same centered STFT as Extract (reflect
+// padding at the start, and at the end on Flush) but are emitted as raw
+// log10(max(mel, 1e-10)) values: waveform normalization and the window-level
+// Compress need the whole window, so callers apply them if they want them.
+// Not safe for concurrent use.
+type Stream struct {
+	tables   *tables
+	hop, pad int
+
+	buf   []float32 // samples from absolute index base
+	base  int       // absolute index of buf[0]
+	total int       // samples written
+	next  int       // next frame index
+
+	window []float32 // reflected frame samples, NFFT
+	frame  []float64
+	re, im []float64
+	power  []float64
+	out    []float32 // NMels
+}
+
+// NewStream returns a Stream with the extractor's sample rate, NFFT, hop and
+// mel filters. WindowSeconds, Padding and Normalize do not apply.
+func (e *Extractor) NewStream() *Stream {
+	n := e.cfg.NFFT
+	return &Stream{
+		tables: e.tables,
+		hop:    e.cfg.HopLength,
+		pad:    n / 2,
+		window: make([]float32, n),
+		frame:  make([]float64, n),
+		re:     make([]float64, n/2),
+		im:     make([]float64, n/2),
+		power:  make([]float64, n/2+1),
+		out:    make([]float32, e.cfg.NMels),
+	}
+}
+
+// Write appends audio and calls emit for each frame that is now complete, in
+// order. The frame (NMels log10 mel values) is only valid during the call.
+func (s *Stream) Write(audio []float32, emit func(frame []float32)) {
+	s.buf = append(s.buf, audio...)
+	s.total += len(audio)
+	for s.total > s.pad && s.next*s.hop+s.pad <= s.total && (s.next+1)*s.hop <= s.total {
+		s.emitNext(emit)
+	}
+	s.trim()
+}
+
+// Flush emits the remaining frames, reflect-padding the end, and resets the
+// stream. Over one window of audio, Write and Flush yield the frames of Extract
+// before Compress: total/HopLength of them, Whisper's extra last frame dropped.
+// Nothing is emitted for audio shorter than NFFT/2+1 samples.
+func (s *Stream) Flush(emit func(frame []float32)) {
+	if s.total > s.pad {
+		for (s.next+1)*s.hop <= s.total {
+			s.emitNext(emit)
+		}
+	}
+	s.Reset()
+}
+
+// Reset discards buffered audio and restarts frame numbering.
+func (s *Stream) Reset() {
+	s.buf = s.buf[:0]
+	s.base, s.total, s.next = 0, 0, 0
+}
+
+// Frames returns the number of frames emitted since the last Reset.
+func (s *Stream) Frames() int { return s.next }
+
+func (s *Stream) emitNext(emit func(frame []float32)) {
+	start := s.next*s.hop - s.pad
+	last := s.total - 1
+	for i := range s.window {
+		p := start + i
+		if p < 0 {
+			p = -p
+		} else if p > last {
+			p = 2*last - p
+		}
+		s.window[i] = s.buf[p-s.base]
+	}
+	s.tables.logMel(s.window, s.frame, s.re, s.im, s.power, s.out, 1)
+	s.next++
+	emit(s.out)
+}
+
+// trim drops samples no later frame needs, compacting the buffer once the
+// dropped prefix is at least half of it.
+func (s *Stream) trim() {
+	keep := s.next*s.hop - s.pad
+	drop := keep - s.base
+	if drop <= 0 || drop < len(s.buf)/2 {
+		return
+	}
+	s.buf = append(s.buf[:0], s.buf[drop:]...)
+	s.base = keep
+}
@@ -0,0 +1,135 @@
+#!/usr/bin/env python3
+"""Regenerates the golden log-mel vectors in this directory.
+
+Each vector is the Smart-Turn v3 input features of one synthetic signal, as
+WhisperFeatureExtractor(feature_size=80, chunk_length=8) computes them with
+do_normalize=True: the last 8 s are kept, the audio is normalized to zero mean
+and unit variance over its real samples, left-padded with zeros to 8 s, then
+run through transformers.audio_utils.spectrogram (centered, reflect padding,
+periodic Hann window of 400, hop 160, power 2, Slaney mel filters, log10 with
+a 1e-10 floor), the last frame dropped, clamped to max-8 and mapped by
+(x+4)/4.
+
+The pipeline is transcribed step by step from the numpy path of
+transformers' WhisperFeatureExtractor and evaluated in float64 with a direct
+DFT, so it shares no code or algorithm with the Go extractor. Pure Python,
+no dependencies; it takes a few minutes.
+
+Output: <name>.f32.gz, gzipped little-endian float32, 80 mels x 800 frames,
+mel-major (mel[m*800+t]) like Extractor.Extract.
+
+The inputs are built from integer arithmetic so Go reproduces them exactly;
+keep signal() in sync with goldenSignal in ../features_test.go.
+"""
+
+import cmath
+import gzip
+import math
+import os
+import struct
+
+RATE, NFFT, HOP, NMELS = 16000, 400, 160, 80
+WINDOW = 8 * RATE
+FRAMES = WINDOW // HOP
+
+CASES = {
+    "short": 40000,  # 2.5 s, left-padded
+    "exact": WINDOW,  # 8 s
+    "long": 160000,  # 10 s, keeps the last 8 s
+    "silence": 48000,  # 3 s of zeros, left-padded
+}
+
+
+def signal(name, n):
+    """Sawtooth, square and LCG noise under a stepped envelope, in 1/16384ths."""
+    if name == "silence":
+        return [0.0] * n
+    out, state = [], 12345
+    for i in range(n):
+        state = (state * 1103515245 + 12345) % (1 << 31)
+        noise = (state >> 16) % 129 - 64
+        saw = (i * 3) % 73 - 36
+        square = 200 if (i // 57) % 2 else -200
+        env = 1 + (i // 4000) % 4
+        out.append(env * (saw * 8 + square + noise) / 16384)
+    return out
+
+
+def f32(x):
+    return struct.unpack("<f", struct.pack("<f", x))[0]
+
+
+def hz_to_mel(f):
+    # mel_scale="slaney"
+    if f < 1000:
+        return 3 * f / 200
+    return 15 + math.log(f / 1000) * 27 / math.log(6.4)
+
+
+def mel_to_hz(m):
+    if m < 15:
+        return 200 * m / 3
+    return 1000 * math.exp(math.log(6.4) * (m - 15) / 27)
+
+
+def mel_filters():
+    """mel_filter_bank(201, 80, 0, 8000, 16000, norm="slaney", mel_scale="slaney")."""
+    bins = NFFT // 2 + 1
+    fft_freqs = [(RATE / 2) * k / (bins - 1) for k in range(bins)]
+    lo, hi = hz_to_mel(0), hz_to_mel(RATE / 2)
+    hz = [mel_to_hz(lo + (hi - lo) * i / (NMELS + 1)) for i in range(NMELS + 2)]
+    filters = []
+    for m in range(NMELS):
+        enorm = 2 / (hz[m + 2] - hz[m])
+        row = []
+        for f in fft_freqs:
+            down = (f - hz[m]) / (hz[m + 1] - hz[m])
+            up = (hz[m + 2] - f) / (hz[m + 2] - hz[m + 1])
+            row.append(max(0.0, min(down, up)) * enorm)
+        filters.append(row)
+    return filters
+
+
+def features(audio, filters, twiddles, window):
+    audio = audio[-WINDOW:]
+    n = len(audio)
+    mean = sum(audio) / n
+    var = sum((x - mean) ** 2 for x in audio) / n
+    scale = 1 / math.sqrt(var + 1e-7)
+    x = [0.0] * (WINDOW - n) + [f32((v - mean) * scale) for v in audio]
+    pad = NFFT // 2
+    x = x[pad:0:-1] + x + x[-2 : -pad - 2 : -1]
+
+    logmel = [[0.0] * FRAMES for _ in range(NMELS)]
+    for t in range(FRAMES):  # the extra last frame is dropped
+        frame = [x[t * HOP + i] * window[i] for i in range(NFFT)]
+        power = []
+        for tw in twiddles:
+            s = sum(map(lambda a, b: a * b, frame, tw))
+            power.append(s.real * s.real + s.imag * s.imag)
+        for m, row in enumerate(filters):
+            v = sum(map(lambda a, b: a * b, row, power))
+            logmel[m][t] = math.log10(max(v, 1e-10))
+
+    top = max(max(r) for r in logmel)
+    return [(max(v, top - 8) + 4) / 4 for r in logmel for v in r]
+
+
+def main():
+    here = os.path.dirname(os.path.abspath(__file__))
+    window = [0.5 - 0.5 * math.cos(2 * math.pi * i / NFFT) for i in range(NFFT)]
+    twiddles = [
+        [cmath.exp(-2j * math.pi * k * i / NFFT) for i in range(NFFT)]
+        for k in range(NFFT // 2 + 1)
+    ]
+    filters = mel_filters()
+    for name, n in CASES.items():
+        mel = features(signal(name, n), filters, twiddles, window)
+        data = struct.pack("<%df" % len(mel), *mel)
+        with gzip.GzipFile(os.path.join(here, name + ".f32.gz"), "wb", mtime=0) as f:
+            f.write(data)
+        print(name, len(mel))
+
+
+if __name__ == "__main__":
+    main()
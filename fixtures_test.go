@@ -0,0 +1,50 @@
+package smartturn
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// noise returns n samples of uniform noise in [-amp, amp].
+func noise(seed int64, n int, amp float32) []float32 {
+	rng := rand.New(rand.NewSource(seed))
+	x := make([]float32, n)
+	for i := range x {
+		x[i] = amp * (2*rng.Float32() - 1)
+	}
+	return x
+}
+
+// voice returns n samples at rate of a voiced-speech stand-in: a 150 Hz
+// fundamental with decaying harmonics up to 3 kHz, peaking near amp.
+func voice(rate, n int, amp float64) []float32 {
+	x := make([]float32, n)
+	for i := range x {
+		var v float64
+		for h := 1; h*150 <= 3000; h++ {
+			v += math.Sin(2*math.Pi*150*float64(h*i)/float64(rate)) / float64(h)
+		}
+		x[i] = float32(amp * v / 2)
+	}
+	return x
+}
+
+// recordEvents returns Callbacks that append every event to *dst, copying
+// SegmentReady audio.
+func recordEvents(dst *[]Event) Callbacks {
+	add := func(ev Event) { *dst = append(*dst, ev) }
+	return Callbacks{
+		OnListeningStarted: func(ev ListeningStarted) { add(ev) },
+		OnListeningStopped: func(ev ListeningStopped) { add(ev) },
+		OnSpeechStart:      func(ev SpeechStart) { add(ev) },
+		OnSpeechEnd:        func(ev SpeechEnd) { add(ev) },
+		OnSegmentReady: func(ev SegmentReady) {
+			ev.Audio = slices.Clone(ev.Audio)
+			add(ev)
+		},
+		OnTurnPrediction: func(ev TurnPrediction) { add(ev) },
+		OnError:          func(err error) { add(Error{Err: err}) },
+		OnOverflow:       func(ev Overflow) { add(ev) },
+	}
+}
@@ -0,0 +1,248 @@
+// Package fft implements the fixed-size FFTs used by feature extraction and
+// the energy VAD.
+package fft
+
+import "math"
+
+// Plan computes in-place complex FFTs of one fixed size whose prime
+// factors are 2, 3 and 5 (e.g. 400 for Whisper, 512 for EnergyVAD), using
+// mixed-radix decimation in time with precomputed twiddles and input
+// permutation. A plan is read-only after construction and may be shared;
+// callers own the re/im buffers.
+type Plan struct {
+	n        int
+	radices  []int     // butterfly radix per stage, first stage first
+	cos, sin []float64 // twiddles e^{-2πik/n}, k < n
+	rev      []int     // input permutation (digit reversal for radices)
+}
+
+// NewPlan returns a plan for size n. It panics unless n >= 2 has no prime
+// factors other than 2, 3 and 5.
+func NewPlan(n int) *Plan {
+	radices := factorRadices(n)
+	if radices == nil {
+		panic("fft: size must have no prime factors other than 2, 3 and 5")
+	}
+	p := &Plan{
+		n:       n,
+		radices: radices,
+		cos:     make([]float64, n),
+		sin:     make([]float64, n),
+	}
+	for k := range p.cos {
+		a := -2 * math.Pi * float64(k) / float64(n)
+		p.cos[k] = math.Cos(a)
+		p.sin[k] = math.Sin(a)
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	p.rev = digitReverse(idx, radices)
+	return p
+}
+
+// factorRadices factors n into stage radices, preferring radix 4. It returns nil
+// if n < 2 or n has another prime factor.
+func factorRadices(n int) []int {
+	if n < 2 {
+		return nil
+	}
+	var radices []int
+	for _, r := range []int{4, 2, 3, 5} {
+		for n%r == 0 {
+			radices = append(radices, r)
+			n /= r
+		}
+	}
+	if n != 1 {
+		return nil
+	}
+	return radices
+}
+
+// digitReverse orders idx so that each stage's sub-transforms are contiguous:
+// the last stage combines radix sub-transforms of the decimated sequences
+// idx[j::radix], each laid out recursively by the earlier stages.
+func digitReverse(idx []int, radices []int) []int {
+	if len(radices) == 0 {
+		return idx
+	}
+	last := len(radices) - 1
+	r := radices[last]
+	out := make([]int, 0, len(idx))
+	for j := 0; j < r; j++ {
+		sub := make([]int, 0, len(idx)/r)
+		for i := j; i < len(idx); i += r {
+			sub = append(sub, idx[i])
+		}
+		out = append(out, digitReverse(sub, radices[:last])...)
+	}
+	return out
+}
+
+// Transform replaces (re, im) with its forward DFT. Both slices have length n.
+func (p *Plan) Transform(re, im []float64) {
+	n := p.n
+	// Apply the permutation by following its cycles in place.
+	for i, r := range p.rev {
+		for r < i {
+			r = p.rev[r]
+		}
+		if r != i {
+			re[i], re[r] = re[r], re[i]
+			im[i], im[r] = im[r], im[i]
+		}
+	}
+	m := 1 // size of the sub-transforms being combined
+	for _, radix := range p.radices {
+		size := m * radix
+		step := n / size // twiddle stride for W_size
+		for start := 0; start < n; start += size {
+			for k := 0; k < m; k++ {
+				switch radix {
+				case 2:
+					p.butterfly2(re, im, start+k, m, k*step)
+				case 4:
+					p.butterfly4(re, im, start+k, m, k*step)
+				default:
+					p.butterflyN(re, im, start+k, m, k*step, radix)
+				}
+			}
+		}
+		m = size
+	}
+}
+
+// butterfly2 combines a[i] and a[i+m] with twiddle W_n^t.
+func (p *Plan) butterfly2(re, im []float64, i, m, t int) {
+	j := i + m
+	wr, wi := p.cos[t], p.sin[t]
+	tr := re[j]*wr - im[j]*wi
+	ti := re[j]*wi + im[j]*wr
+	re[j], im[j] = re[i]-tr, im[i]-ti
+	re[i] += tr
+	im[i] += ti
+}
+
+// butterfly4 combines a[i+q*m], q < 4, with twiddles W_n^{q*t}.
+func (p *Plan) butterfly4(re, im []float64, i, m, t int) {
+	var xr, xi [4]float64
+	xr[0], xi[0] = re[i], im[i]
+	for q := 1; q < 4; q++ {
+		w := q * t
+		a, b := re[i+q*m], im[i+q*m]
+		xr[q] = a*p.cos[w] - b*p.sin[w]
+		xi[q] = a*p.sin[w] + b*p.cos[w]
+	}
+	// Radix-4 DFT; multiplying by -i maps (r, i) to (i, -r).
+	ar, ai := xr[0]+xr[2], xi[0]+xi[2]
+	br, bi := xr[0]-xr[2], xi[0]-xi[2]
+	cr, ci := xr[1]+xr[3], xi[1]+xi[3]
+	dr, di := xi[1]-xi[3], xr[3]-xr[1] // -i * (x1 - x3)
+	re[i], im[i] = ar+cr, ai+ci
+	re[i+m], im[i+m] = br+dr, bi+di
+	re[i+2*m], im[i+2*m] = ar-cr, ai-ci
+	re[i+3*m], im[i+3*m] = br-dr, bi-di
+}
+
+// butterflyN is the generic radix butterfly (used for 3 and 5).
+func (p *Plan) butterflyN(re, im []float64, i, m, t, radix int) {
+	var xr, xi, yr, yi [5]float64
+	for q := 0; q < radix; q++ {
+		w := q * t
+		a, b := re[i+q*m], im[i+q*m]
+		xr[q] = a*p.cos[w] - b*p.sin[w]
+		xi[q] = a*p.sin[w] + b*p.cos[w]
+	}
+	stride := p.n / radix // W_radix = W_n^stride
+	for q := 0; q < radix; q++ {
+		sr, si := xr[0], xi[0]
+		for j := 1; j < radix; j++ {
+			w := (j * q % radix) * stride
+			sr += xr[j]*p.cos[w] - xi[j]*p.sin[w]
+			si += xr[j]*p.sin[w] + xi[j]*p.cos[w]
+		}
+		yr[q], yi[q] = sr, si
+	}
+	for q := 0; q < radix; q++ {
+		re[i+q*m], im[i+q*m] = yr[q], yi[q]
+	}
+}
+
+// Real computes spectra of real frames of even length n with one
+// complex FFT of length n/2: even and odd samples are packed as real and
+// imaginary parts, and the two interleaved spectra are separated afterwards.
+// Read-only after construction; callers supply scratch buffers.
+type Real struct {
+	n        int
+	half     *Plan
+	cos, sin []float64 // twiddles e^{-2πik/n}, k <= n/2
+}
+
+// NewReal returns a real-input plan for even size n; n/2 must be a valid
+// Plan size.
+func NewReal(n int) *Real {
+	if n%2 != 0 {
+		panic("fft: real size must be even")
+	}
+	p := &Real{
+		n:    n,
+		half: NewPlan(n / 2),
+		cos:  make([]float64, n/2+1),
+		sin:  make([]float64, n/2+1),
+	}
+	for k := range p.cos {
+		a := -2 * math.Pi * float64(k) / float64(n)
+		p.cos[k] = math.Cos(a)
+		p.sin[k] = math.Sin(a)
+	}
+	return p
+}
+
+// Power writes |X[k]|² for k = 0..n/2 of the real frame x (length n) into
+// power (length n/2+1). re and im are scratch of length n/2.
+func (p *Real) Power(x []float64, re, im []float64, power []float64) {
+	p.transform(x, re, im)
+	for k := range power {
+		xr, xi := p.bin(re, im, k)
+		power[k] = xr*xr + xi*xi
+	}
+}
+
+// Spectrum writes X[k] for k = 0..n/2 of the real frame x (length n) into
+// xr and xi (length n/2+1). re and im are scratch of length n/2.
+func (p *Real) Spectrum(x []float64, re, im []float64, xr, xi []float64) {
+	p.transform(x, re, im)
+	for k := range xr {
+		xr[k], xi[k] = p.bin(re, im, k)
+	}
+}
+
+// transform packs even and odd samples of x as real and imaginary parts and
+// runs the half-size complex FFT on them.
+func (p *Real) transform(x []float64, re, im []float64) {
+	for k := range re {
+		re[k] = x[2*k]
+		im[k] = x[2*k+1]
+	}
+	p.half.Transform(re, im)
+}
+
+// bin separates X[k] from the packed half-size spectrum: X[k] = E[k] + W_n^k
+// O[k], where E = (Z[k] + conj Z[h-k]) / 2 and O = (Z[k] - conj Z[h-k]) / 2i
+// are the spectra of the even and odd samples.
+func (p *Real) bin(re, im []float64, k int) (float64, float64) {
+	h := p.n / 2
+	zr, zi := re[k%h], im[k%h]
+	cr, ci := re[(h-k)%h], -im[(h-k)%h]
+	er, ei := (zr+cr)/2, (zi+ci)/2
+	or, oi := (zi-ci)/2, -(zr-cr)/2
+	wr, wi := p.cos[k], p.sin[k]
+	return er + or*wr - oi*wi, ei + or*wi + oi*wr
+}
+
+// SupportsReal reports whether NewReal accepts size n.
+func SupportsReal(n int) bool {
+	return n%2 == 0 && factorRadices(n/2) != nil
+}
@@ -0,0 +1,120 @@
+package fft
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+var sizes = []int{200, 256, 400, 512}
+
+// naiveDFT returns the forward DFT of (re, im) by direct summation, as the
+// mel path computed the power spectrum before it used Plan.
+func naiveDFT(re, im []float64) (outRe, outIm []float64) {
+	n := len(re)
+	outRe, outIm = make([]float64, n), make([]float64, n)
+	for k := 0; k < n; k++ {
+		for t := 0; t < n; t++ {
+			a := -2 * math.Pi * float64(k) * float64(t) / float64(n)
+			c, s := math.Cos(a), math.Sin(a)
+			outRe[k] += re[t]*c - im[t]*s
+			outIm[k] += re[t]*s + im[t]*c
+		}
+	}
+	return outRe, outIm
+}
+
+func randomFrame(rng *rand.Rand, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = rng.Float64()*2 - 1
+	}
+	return x
+}
+
+// tolerance bounds the error of an n-point transform of unit-range input:
+// the naive sum accumulates O(n) rounding error in a result of size O(√n).
+func tolerance(n int) float64 { return 1e-12 * float64(n) }
+
+func TestTransformMatchesDFT(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range append(sizes, 2, 3, 5, 6, 12, 100) {
+		re, im := randomFrame(rng, n), randomFrame(rng, n)
+		wantRe, wantIm := naiveDFT(re, im)
+		NewPlan(n).Transform(re, im)
+		for k := range re {
+			if d := math.Hypot(re[k]-wantRe[k], im[k]-wantIm[k]); d > tolerance(n) {
+				t.Fatalf("n=%d: bin %d off by %g", n, k, d)
+			}
+		}
+	}
+}
+
+func TestRealMatchesDFT(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range sizes {
+		x := randomFrame(rng, n)
+		wantRe, wantIm := naiveDFT(x, make([]float64, n))
+		p := NewReal(n)
+		re, im := make([]float64, n/2), make([]float64, n/2)
+		power := make([]float64, n/2+1)
+		xr, xi := make([]float64, n/2+1), make([]float64, n/2+1)
+		p.Power(x, re, im, power)
+		p.Spectrum(x, re, im, xr, xi)
+		for k := range power {
+			want := wantRe[k]*wantRe[k] + wantIm[k]*wantIm[k]
+			if d := math.Abs(power[k] - want); d > tolerance(n)*(1+math.Sqrt(want)) {
+				t.Fatalf("n=%d: power bin %d = %g, want %g", n, k, power[k], want)
+			}
+			if d := math.Hypot(xr[k]-wantRe[k], xi[k]-wantIm[k]); d > tolerance(n) {
+				t.Fatalf("n=%d: spectrum bin %d off by %g", n, k, d)
+			}
+		}
+	}
+}
+
+func TestSupportsReal(t *testing.T) {
+	for n, want := range map[int]bool{400: true, 512: true, 200: true, 2: false, 401: false, 14: false, 1000: true} {
+		if got := SupportsReal(n); got != want {
+			t.Errorf("SupportsReal(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func BenchmarkPower(b *testing.B) {
+	for _, n := range sizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			x := randomFrame(rand.New(rand.NewSource(3)), n)
+			p := NewReal(n)
+			re, im := make([]float64, n/2), make([]float64, n/2)
+			power := make([]float64, n/2+1)
+			b.ReportAllocs()
+			for b.Loop() {
+				p.Power(x, re, im, power)
+			}
+		})
+	}
+}
+
+// BenchmarkNaivePower is the direct DFT the mel path used before Real, for
+// comparison with BenchmarkPower.
+func BenchmarkNaivePower(b *testing.B) {
+	for _, n := range sizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			x := randomFrame(rand.New(rand.NewSource(3)), n)
+			power := make([]float64, n/2+1)
+			for b.Loop() {
+				for k := range power {
+					var re, im float64
+					for t, v := range x {
+						a := -2 * math.Pi * float64(k) * float64(t) / float64(n)
+						re += v * math.Cos(a)
+						im += v * math.Sin(a)
+					}
+					power[k] = re*re + im*im
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package smartturn
+
+import (
+	"os"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// ModelsConfig selects the ONNX models LoadModels loads. A model whose path is
+// empty is not loaded; engines that need it must then supply Config.VAD or
+// Config.TurnPredictor.
+type ModelsConfig struct {
+	SileroVADModelPath string // path to silero_vad.onnx
+	SmartTurnModelPath string // path to smart-turn-v3.2-cpu.onnx
+	// ONNXRuntimeLibPath is the ONNX Runtime shared library; see Config.ONNXRuntimeLibPath.
+	ONNXRuntimeLibPath string
+}
+
+// Models holds ONNX sessions whose weights are loaded once and shared by any
+// number of engines (Config.Models). Each engine keeps its own recurrent state
+// and I/O tensors; runs from different engines and goroutines go to the
+// shared sessions concurrently, which ONNX Runtime supports. Models is safe
+// for concurrent use.
+type Models struct {
+	vad  *sharedSession // nil if not loaded
+	turn *sharedSession
+}
+
+// LoadModels initializes ONNX Runtime if needed and loads the models named in
+// cfg. Close it after every engine using it has been closed.
+func LoadModels(cfg ModelsConfig) (*Models, error) {
+	if cfg.SileroVADModelPath == "" && cfg.SmartTurnModelPath == "" {
+		return nil, configError("ModelsConfig", "ModelsConfig names no model")
+	}
+	for _, p := range []struct{ field, path string }{
+		{"SileroVADModelPath", cfg.SileroVADModelPath},
+		{"SmartTurnModelPath", cfg.SmartTurnModelPath},
+	} {
+		if p.path == "" {
+			continue
+		}
+		if _, err := os.Stat(p.path); err != nil {
+			return nil, &StageError{Stage: StageConfig, Field: p.field, Err: err}
+		}
+	}
+	if err := initORT(cfg.ONNXRuntimeLibPath); err != nil {
+		return nil, err
+	}
+	m := &Models{}
+	if cfg.SileroVADModelPath != "" {
+		sess, err := ort.NewDynamicAdvancedSession(cfg.SileroVADModelPath,
+			[]string{"input", "state", "sr"},
+			[]string{"output", "stateN"},
+			nil)
+		if err != nil {
+			return nil, &StageError{Stage: StageLoad, Err: err}
+		}
+		m.vad = &sharedSession{sess: sess}
+	}
+	if cfg.SmartTurnModelPath != "" {
+		// Model output is named "logits" (sigmoid probability), not "output"
+		sess, err := ort.NewDynamicAdvancedSession(cfg.SmartTurnModelPath,
+			[]string{"input_features"},
+			[]string{"logits"},
+			nil)
+		if err != nil {
+			_ = m.Close()
+			return nil, &StageError{Stage: StageLoad, Err: err}
+		}
+		m.turn = &sharedSession{sess: sess}
+	}
+	return m, nil
+}
+
+// Close destroys the sessions after waiting for runs in progress. Engines
+// still using m afterwards fail inference with ErrModelsClosed.
+func (m *Models) Close() error {
+	var first error
+	for _, s := range []*sharedSession{m.vad, m.turn} {
+		if s == nil {
+			continue
+		}
+		if err := s.close(); err != nil && first == nil {
+			first = &StageError{Stage: StageClose, Err: err}
+		}
+	}
+	return first
+}
+
+// initORT sets the shared library path and initializes the ONNX Runtime
+// environment unless it already is. ONNX Runtime allows one environment per
+// process; multiple engines (e.g. mic + system audio) must share it.
+func initORT(libPath string) error {
+	if libPath != "" {
+		ort.SetSharedLibraryPath(libPath)
+	} else if path := os.Getenv(EnvONNXRuntimeLib); path != "" {
+		ort.SetSharedLibraryPath(path)
+	}
+	if !ort.IsInitialized() {
+		if err := ort.InitializeEnvironment(); err != nil {
+			return &StageError{Stage: StageRuntime, Err: err}
+		}
+	}
+	return nil
+}
+
+// sharedSession guards a session shared by several engines: runs hold the
+// read lock so they proceed concurrently, and close takes the write lock so
+// the session is never destroyed under a run.
+type sharedSession struct {
+	mu   sync.RWMutex
+	sess *ort.DynamicAdvancedSession // nil once closed
+}
+
+func (s *sharedSession) run(inputs, outputs []ort.Value) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.sess == nil {
+		return ErrModelsClosed
+	}
+	return s.sess.Run(inputs, outputs)
+}
+
+func (s *sharedSession) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sess == nil {
+		return nil
+	}
+	err := s.sess.Destroy()
+	s.sess = nil
+	return err
+}
+
+// destroyValues destroys every tensor and returns the first error.
+func destroyValues(groups ...[]ort.Value) error {
+	var first error
+	for _, vs := range groups {
+		for _, v := range vs {
+			if err := v.Destroy(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
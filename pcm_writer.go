@@ -0,0 +1,172 @@
+package smartturn
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// SampleEncoding identifies how a single sample is stored in a byte stream.
+type SampleEncoding int
+
+const (
+	EncodingInt16   SampleEncoding = iota + 1 // signed 16-bit PCM
+	EncodingInt32                             // signed 32-bit PCM
+	EncodingFloat32                           // IEEE 754 float32, nominal range [-1, 1]
+	EncodingFloat64                           // IEEE 754 float64, nominal range [-1, 1]
+	EncodingUint8                             // unsigned 8-bit PCM, 128 = silence
+)
+
+// SampleFormat describes raw PCM bytes: the sample encoding and byte order.
+// BigEndian is ignored for EncodingUint8.
+type SampleFormat struct {
+	Encoding  SampleEncoding
+	BigEndian bool
+}
+
+// Common sample formats.
+var (
+	FormatS16LE = SampleFormat{Encoding: EncodingInt16}
+	FormatS16BE = SampleFormat{Encoding: EncodingInt16, BigEndian: true}
+	FormatS32LE = SampleFormat{Encoding: EncodingInt32}
+	FormatS32BE = SampleFormat{Encoding: EncodingInt32, BigEndian: true}
+	FormatF32LE = SampleFormat{Encoding: EncodingFloat32}
+	FormatF32BE = SampleFormat{Encoding: EncodingFloat32, BigEndian: true}
+	FormatF64LE = SampleFormat{Encoding: EncodingFloat64}
+	FormatF64BE = SampleFormat{Encoding: EncodingFloat64, BigEndian: true}
+	FormatU8    = SampleFormat{Encoding: EncodingUint8}
+)
+
+// BytesPerSample returns the encoded size of one sample, or 0 for an unknown encoding.
+func (f SampleFormat) BytesPerSample() int {
+	switch f.Encoding {
+	case EncodingUint8:
+		return 1
+	case EncodingInt16:
+		return 2
+	case EncodingInt32, EncodingFloat32:
+		return 4
+	case EncodingFloat64:
+		return 8
+	default:
+		return 0
+	}
+}
+
+const pcmWriterBatch = 4096 // samples decoded per PushSamples call
+
+// PCMWriter decodes raw PCM bytes into an Engine. It implements io.Writer and
+// io.ReaderFrom, so a socket or device stream can be copied straight in with
+// io.Copy. Bytes of a sample split across writes are kept until the rest
+// arrives. Like Engine, it is not goroutine-safe.
+type PCMWriter struct {
+	e      *Engine
+	format SampleFormat
+	size   int
+	order  binary.ByteOrder
+
+	partial    [8]byte // bytes of a sample split across writes
+	partialLen int
+	samples    []float32 // decoded batch, reused
+	readBuf    []byte    // ReadFrom buffer, allocated on first use
+}
+
+// NewPCMWriter returns a writer that decodes bytes in the given format and
+// pushes them to e via PushSamples. Channel layout and rate follow e's Config.
+func NewPCMWriter(e *Engine, format SampleFormat) (*PCMWriter, error) {
+	size := format.BytesPerSample()
+	if size == 0 {
+		return nil, configError("Format", "unknown sample encoding")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if format.BigEndian {
+		order = binary.BigEndian
+	}
+	return &PCMWriter{
+		e:       e,
+		format:  format,
+		size:    size,
+		order:   order,
+		samples: make([]float32, 0, pcmWriterBatch),
+	}, nil
+}
+
+// Write decodes p and pushes the samples to the engine. It always consumes all
+// of p; a non-nil error comes from the engine.
+func (w *PCMWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if w.partialLen > 0 {
+		c := copy(w.partial[w.partialLen:w.size], p)
+		w.partialLen += c
+		p = p[c:]
+		if w.partialLen < w.size {
+			return n, nil
+		}
+		w.samples = append(w.samples, w.decode(w.partial[:w.size]))
+		w.partialLen = 0
+	}
+	for len(p) >= w.size {
+		w.samples = append(w.samples, w.decode(p[:w.size]))
+		p = p[w.size:]
+		if len(w.samples) == cap(w.samples) {
+			if err := w.push(); err != nil {
+				return n, err
+			}
+		}
+	}
+	w.partialLen = copy(w.partial[:], p)
+	return n, w.push()
+}
+
+// ReadFrom reads r until EOF, decoding and pushing everything read.
+func (w *PCMWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.readBuf == nil {
+		w.readBuf = make([]byte, pcmWriterBatch*w.size)
+	}
+	var total int64
+	for {
+		n, err := r.Read(w.readBuf)
+		if n > 0 {
+			total += int64(n)
+			if _, werr := w.Write(w.readBuf[:n]); werr != nil {
+				return total, werr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// Flush discards an incomplete trailing sample and flushes the engine.
+func (w *PCMWriter) Flush() error {
+	w.partialLen = 0
+	return w.e.Flush()
+}
+
+func (w *PCMWriter) push() error {
+	if len(w.samples) == 0 {
+		return nil
+	}
+	err := w.e.PushSamples(w.samples)
+	w.samples = w.samples[:0]
+	return err
+}
+
+func (w *PCMWriter) decode(b []byte) float32 {
+	switch w.format.Encoding {
+	case EncodingUint8:
+		return (float32(b[0]) - 128) / 128
+	case EncodingInt16:
+		return float32(int16(w.order.Uint16(b))) / 32768
+	case EncodingInt32:
+		return float32(float64(int32(w.order.Uint32(b))) / 2147483648)
+	case EncodingFloat32:
+		return math.Float32frombits(w.order.Uint32(b))
+	default:
+		return float32(math.Float64frombits(w.order.Uint64(b)))
+	}
+}
@@ -0,0 +1,139 @@
+package smartturn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+// pcmValues returns n samples that every SampleFormat encodes exactly.
+func pcmValues(n int) []float32 {
+	x := make([]float32, n)
+	for i := range x {
+		x[i] = float32((i*37)%256-128) / 128
+	}
+	return x
+}
+
+// encodePCM encodes x in f, independently of PCMWriter.decode.
+func encodePCM(f SampleFormat, x []float32) []byte {
+	var order binary.AppendByteOrder = binary.LittleEndian
+	if f.BigEndian {
+		order = binary.BigEndian
+	}
+	var out []byte
+	for _, v := range x {
+		switch f.Encoding {
+		case EncodingUint8:
+			out = append(out, byte(int(v*128)+128))
+		case EncodingInt16:
+			out = order.AppendUint16(out, uint16(int16(v*32768)))
+		case EncodingInt32:
+			out = order.AppendUint32(out, uint32(int32(float64(v)*(1<<31))))
+		case EncodingFloat32:
+			out = order.AppendUint32(out, math.Float32bits(v))
+		case EncodingFloat64:
+			out = order.AppendUint64(out, math.Float64bits(float64(v)))
+		}
+	}
+	return out
+}
+
+// chunkRecorder returns a started engine that appends every chunk it
+// processes to *dst.
+func chunkRecorder(t *testing.T, dst *[]float32) *Engine {
+	t.Helper()
+	c := testConfig(RequiredSampleRate, RequiredChunkSize)
+	c.VAD = indexVAD{}
+	c.TurnPredictor = &recordPredictor{}
+	e, err := New(c, Callbacks{OnChunk: func(ev Chunk) { *dst = append(*dst, ev.Audio...) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+	e.Start()
+	return e
+}
+
+// TestPCMWriterFormats decodes every format written byte by byte, in odd
+// pieces and through ReadFrom, and compares the engine's chunks with pushing
+// the values directly. A partial sample before Flush is dropped, so the
+// audio after it decodes from a sample boundary.
+func TestPCMWriterFormats(t *testing.T) {
+	values := pcmValues(3*RequiredChunkSize + 37)
+	modes := map[string]func(w *PCMWriter, p []byte) error{
+		"bytes": func(w *PCMWriter, p []byte) error {
+			for i := range p {
+				if _, err := w.Write(p[i : i+1]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		"pieces": func(w *PCMWriter, p []byte) error {
+			for len(p) > 0 {
+				n := min(7, len(p))
+				if _, err := w.Write(p[:n]); err != nil {
+					return err
+				}
+				p = p[n:]
+			}
+			return nil
+		},
+		"ReadFrom": func(w *PCMWriter, p []byte) error {
+			n, err := w.ReadFrom(iotest.OneByteReader(bytes.NewReader(p)))
+			if err == nil && n != int64(len(p)) {
+				err = io.ErrShortWrite
+			}
+			return err
+		},
+	}
+
+	var want []float32
+	ref := chunkRecorder(t, &want)
+	for i := 0; i < 2; i++ {
+		if err := ref.PushSamples(values); err != nil {
+			t.Fatal(err)
+		}
+		if err := ref.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, f := range []SampleFormat{
+		FormatU8, FormatS16LE, FormatS16BE, FormatS32LE, FormatS32BE,
+		FormatF32LE, FormatF32BE, FormatF64LE, FormatF64BE,
+	} {
+		data := encodePCM(f, values)
+		for name, write := range modes {
+			var got []float32
+			w, err := NewPCMWriter(chunkRecorder(t, &got), f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := write(w, data); err != nil {
+				t.Fatal(err)
+			}
+			// All but the last byte of one more sample, dropped by Flush.
+			if err := write(w, data[:f.BytesPerSample()-1]); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if err := write(w, data); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%+v, %s: decoded %d samples differ from PushSamples' %d", f, name, len(got), len(want))
+			}
+		}
+	}
+}
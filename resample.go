@@ -0,0 +1,153 @@
+package smartturn
+
+import "math"
+
+const (
+	resampleZeroCrossings = 16   // sinc zero crossings kept on each side of the kernel centre
+	resampleRolloff       = 0.94 // passband edge as a fraction of the lower Nyquist frequency
+	resampleKaiserBeta    = 8.6  // Kaiser window shape (~80 dB stopband)
+	maxResamplePhases     = 1024 // largest reduced upsampling factor accepted
+)
+
+// resampler converts a mono stream between two fixed sample rates with an
+// anti-aliased windowed-sinc polyphase filter. State carries across calls so
+// chunk boundaries are seamless. Not safe for concurrent use.
+type resampler struct {
+	up, down int       // reduced ratio: outRate/inRate = up/down
+	half     int       // taps on each side of the reference sample
+	filters  []float32 // up phases × 2*half taps, each phase normalized to unity gain
+	zeros    []float32 // half samples of silence used by flush
+
+	hist  []float32 // unconsumed input; the first half-1 samples are history
+	idx   int       // hist index of the reference sample for the next output
+	phase int       // fractional part of the next output position, in 1/up units
+}
+
+// resampleRatio reduces outRate/inRate to lowest terms.
+func resampleRatio(inRate, outRate int) (up, down int) {
+	a, b := inRate, outRate
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return outRate / a, inRate / a
+}
+
+func newResampler(inRate, outRate int) *resampler {
+	up, down := resampleRatio(inRate, outRate)
+	// Cutoff in cycles per input sample; when decimating it must sit below the
+	// output Nyquist to suppress aliasing.
+	cutoff := 0.5 * resampleRolloff
+	if down > up {
+		cutoff *= float64(up) / float64(down)
+	}
+	half := int(math.Ceil(resampleZeroCrossings / (2 * cutoff)))
+	taps := 2 * half
+	filters := make([]float32, up*taps)
+	i0Beta := besselI0(resampleKaiserBeta)
+	for p := 0; p < up; p++ {
+		h := filters[p*taps : (p+1)*taps]
+		var sum float64
+		coeffs := make([]float64, taps)
+		for k := range coeffs {
+			// Distance in input samples between the output instant and tap k.
+			d := float64(p)/float64(up) + float64(half-1-k)
+			x := d / float64(half)
+			if x <= -1 || x >= 1 {
+				continue
+			}
+			w := besselI0(resampleKaiserBeta*math.Sqrt(1-x*x)) / i0Beta
+			coeffs[k] = 2 * cutoff * sinc(2*cutoff*d) * w
+			sum += coeffs[k]
+		}
+		for k, c := range coeffs {
+			h[k] = float32(c / sum)
+		}
+	}
+	r := &resampler{
+		up:      up,
+		down:    down,
+		half:    half,
+		filters: filters,
+		zeros:   make([]float32, half),
+	}
+	r.reset()
+	return r
+}
+
+// process resamples src, appends the output to dst, and returns the extended
+// slice. Output lags input by half samples; flush drains it at end of stream.
+// Internal buffers are reused, so steady-state calls do not allocate beyond
+// growing dst.
+func (r *resampler) process(dst, src []float32) []float32 {
+	r.hist = append(r.hist, src...)
+	taps := 2 * r.half
+	for r.idx+r.half < len(r.hist) {
+		start := r.idx - r.half + 1
+		h := r.filters[r.phase*taps : (r.phase+1)*taps]
+		x := r.hist[start : start+taps]
+		var acc float32
+		for k, w := range h {
+			acc += w * x[k]
+		}
+		dst = append(dst, acc)
+		r.phase += r.down
+		r.idx += r.phase / r.up
+		r.phase %= r.up
+	}
+	// Drop input that no future output can reach.
+	if drop := min(r.idx-r.half+1, len(r.hist)); drop > 0 {
+		n := copy(r.hist, r.hist[drop:])
+		r.hist = r.hist[:n]
+		r.idx -= drop
+	}
+	return dst
+}
+
+// flush pushes enough silence through the filter to emit output for every
+// input sample received so far.
+func (r *resampler) flush(dst []float32) []float32 {
+	return r.process(dst, r.zeros)
+}
+
+// resampleAll resamples a complete signal from a fresh stream state into a new
+// slice of exactly len(src)*up/down samples (src length a multiple of down),
+// leaving the resampler reset.
+func (r *resampler) resampleAll(src []float32) []float32 {
+	n := len(src) * r.up / r.down
+	r.reset()
+	dst := r.process(make([]float32, 0, n+r.half), src)
+	dst = r.flush(dst)
+	r.reset()
+	for len(dst) < n {
+		dst = append(dst, 0)
+	}
+	return dst[:n]
+}
+
+// reset discards buffered input and restarts the stream at phase zero.
+func (r *resampler) reset() {
+	r.hist = append(r.hist[:0], r.zeros[:r.half-1]...)
+	r.idx = r.half - 1
+	r.phase = 0
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// besselI0 is the zeroth-order modified Bessel function of the first kind.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	q := x * x / 4
+	for k := 1; k < 64; k++ {
+		term *= q / float64(k*k)
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
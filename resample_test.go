@@ -0,0 +1,164 @@
+package smartturn
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// resamplePairs are the input rates the engine resamples from, with the VAD
+// rates they feed, and the 8 kHz to 16 kHz upsampler used for Smart-Turn.
+var resamplePairs = [][2]int{
+	{8000, 16000}, {11025, 16000}, {22050, 16000}, {24000, 16000},
+	{32000, 16000}, {44100, 16000}, {48000, 16000}, {96000, 16000},
+	{16000, 8000}, {44100, 8000}, {48000, 8000},
+}
+
+func tone(rate, n int, hz float64) []float32 {
+	x := make([]float32, n)
+	for i := range x {
+		x[i] = float32(math.Sin(2 * math.Pi * hz * float64(i) / float64(rate)))
+	}
+	return x
+}
+
+// resamplePieces resamples src through process in pieces of an odd size,
+// then flushes.
+func resamplePieces(r *resampler, src []float32) []float32 {
+	var out []float32
+	for len(src) > 0 {
+		n := min(1001, len(src))
+		out = r.process(out, src[:n])
+		src = src[n:]
+	}
+	return r.flush(out)
+}
+
+// amplitude is the peak amplitude of a sine from its RMS, skipping the
+// filter's start-up and tail.
+func amplitude(x []float32, skip int) float64 {
+	x = x[skip : len(x)-skip]
+	var sum float64
+	for _, v := range x {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(2 * sum / float64(len(x)))
+}
+
+// TestResamplerLength checks that a flushed stream of n input samples yields
+// ceil(n*out/in) output samples, however the input is split.
+func TestResamplerLength(t *testing.T) {
+	for _, p := range resamplePairs {
+		r := newResampler(p[0], p[1])
+		for _, n := range []int{1, 511, p[0], 3*p[0] + 7} {
+			src := tone(p[0], n, 440)
+			got := resamplePieces(r, src)
+			want := (n*r.up + r.down - 1) / r.down
+			if len(got) != want {
+				t.Errorf("%d→%d Hz, %d samples: %d out, want %d", p[0], p[1], n, len(got), want)
+			}
+			r.reset()
+			whole := r.flush(r.process(nil, src))
+			if !reflect.DeepEqual(got, whole) {
+				t.Errorf("%d→%d Hz, %d samples: output depends on how the input is split", p[0], p[1], n)
+			}
+			r.reset()
+		}
+	}
+}
+
+// TestResamplerPassband passes a 1 kHz tone at unity gain.
+func TestResamplerPassband(t *testing.T) {
+	for _, p := range resamplePairs {
+		r := newResampler(p[0], p[1])
+		out := resamplePieces(r, tone(p[0], p[0], 1000))
+		if a := amplitude(out, 2*r.half); math.Abs(a-1) > 1e-3 {
+			t.Errorf("%d→%d Hz: 1 kHz amplitude %.5f, want 1", p[0], p[1], a)
+		}
+	}
+}
+
+// TestResamplerStopband feeds a tone above the output Nyquist frequency,
+// which must not alias into the output.
+func TestResamplerStopband(t *testing.T) {
+	for _, p := range resamplePairs {
+		if p[0] <= p[1] {
+			continue
+		}
+		hz := math.Min(0.75*float64(p[1]), 0.45*float64(p[0]))
+		r := newResampler(p[0], p[1])
+		out := resamplePieces(r, tone(p[0], p[0], hz))
+		if a := amplitude(out, 2*r.half); a > 1e-4 {
+			t.Errorf("%d→%d Hz: %.0f Hz tone leaks with amplitude %.2g", p[0], p[1], hz, a)
+		}
+	}
+}
+
+// TestResamplerNoAllocs checks that process does not allocate per chunk once
+// its buffers have grown.
+func TestResamplerNoAllocs(t *testing.T) {
+	for _, p := range resamplePairs {
+		r := newResampler(p[0], p[1])
+		chunk := tone(p[0], RequiredChunkSize*p[0]/p[1], 440)
+		dst := make([]float32, 0, 2*RequiredChunkSize)
+		dst = r.process(dst[:0], chunk)
+		if allocs := testing.AllocsPerRun(100, func() { dst = r.process(dst[:0], chunk) }); allocs != 0 {
+			t.Errorf("%d→%d Hz: %v allocations per chunk", p[0], p[1], allocs)
+		}
+	}
+}
+
+// TestResampleAllLength checks resampleAll's exact output length and that it
+// leaves the stream reset.
+func TestResampleAllLength(t *testing.T) {
+	r := newResampler(NarrowbandSampleRate, RequiredSampleRate)
+	for _, n := range []int{0, 1, 256, 8000, 64000} {
+		src := tone(NarrowbandSampleRate, n, 440)
+		if got := r.resampleAll(src); len(got) != 2*n {
+			t.Errorf("resampleAll of %d samples: %d out, want %d", n, len(got), 2*n)
+		}
+		if got, want := r.resampleAll(src), newResampler(NarrowbandSampleRate, RequiredSampleRate).resampleAll(src); !reflect.DeepEqual(got, want) {
+			t.Errorf("resampleAll of %d samples depends on the previous call", n)
+		}
+	}
+}
+
+// TestFlushRestartsResampler pushes audio after Flush: the engine must
+// process it like a fresh engine, with no resampler history or phase left.
+func TestFlushRestartsResampler(t *testing.T) {
+	const rate = 44100
+	first, second := tone(rate, 12345, 440), tone(rate, 3*rate, 300)
+	newEngine := func(dst *[]float32) *Engine {
+		c := testConfig(rate, RequiredChunkSize)
+		c.VAD = indexVAD{}
+		c.TurnPredictor = &recordPredictor{}
+		e, err := New(c, Callbacks{OnChunk: func(ev Chunk) { *dst = append(*dst, ev.Audio...) }})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(e.Close)
+		e.Start()
+		return e
+	}
+	var got, want []float32
+	e := newEngine(&got)
+	for _, audio := range [][]float32{first, second} {
+		got = got[:0]
+		if err := e.PushSamples(audio); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fresh := newEngine(&want)
+	if err := fresh.PushSamples(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := fresh.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("audio after Flush resampled differently from a fresh stream")
+	}
+}
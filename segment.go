@@ -2,7 +2,8 @@ package smartturn
 
 import "sync"
 
-// chunkPool reuses 512-sample buffers to avoid per-chunk allocations in the hot path.
+// chunkPool reuses 512-sample buffers to avoid per-chunk allocations in the hot
+// path. Engines with smaller chunks slice them to cfg.chunkSize.
 var chunkPool = sync.Pool{
 	New: func() interface{} { return make([]float32, RequiredChunkSize) },
 }
@@ -28,6 +29,14 @@ type configSegment struct {
 }
 
 func newSegmenter(sampleRate, chunkSize, preSpeechMs, stopMs int, maxDurationSec float32) *segmenter {
+	cfg := newConfigSegment(sampleRate, chunkSize, preSpeechMs, stopMs, maxDurationSec)
+	return &segmenter{
+		cfg:       cfg,
+		preBuffer: make([][]float32, cfg.preChunks),
+	}
+}
+
+func newConfigSegment(sampleRate, chunkSize, preSpeechMs, stopMs int, maxDurationSec float32) configSegment {
 	chunkMs := float64(chunkSize) / float64(sampleRate) * 1000
 	preChunks := ceilDiv(preSpeechMs, max(1, int(chunkMs)))
 	if preChunks <= 0 {
@@ -42,17 +51,37 @@ func newSegmenter(sampleRate, chunkSize, preSpeechMs, stopMs int, maxDurationSec
 	if maxChunks <= 0 {
 		maxChunks = 1
 	}
-	return &segmenter{
-		cfg: configSegment{
-			preChunks: preChunks,
-			stopChunks: stopChunks,
-			maxChunks:  maxChunks,
-			chunkSize:  chunkSize,
-		},
-		preBuffer: make([][]float32, preChunks),
+	return configSegment{
+		preChunks:  preChunks,
+		stopChunks: stopChunks,
+		maxChunks:  maxChunks,
+		chunkSize:  chunkSize,
 	}
 }
 
+// reconfigure applies new limits between chunks. The pre-buffer keeps its
+// newest chunks; an open segment that already exceeds the new stop or max
+// limit ends on the next chunk.
+func (s *segmenter) reconfigure(cfg configSegment) {
+	if cfg.preChunks != s.cfg.preChunks {
+		ring := make([][]float32, cfg.preChunks)
+		keep := min(s.preBufCount, cfg.preChunks)
+		startIdx := (s.preBufIdx - s.preBufCount + s.cfg.preChunks) % s.cfg.preChunks
+		for i := 0; i < s.preBufCount; i++ {
+			chunk := s.preBuffer[(startIdx+i)%s.cfg.preChunks]
+			if j := i - (s.preBufCount - keep); j >= 0 {
+				ring[j] = chunk
+			} else if chunk != nil {
+				chunkPool.Put(chunk)
+			}
+		}
+		s.preBuffer = ring
+		s.preBufCount = keep
+		s.preBufIdx = keep % cfg.preChunks
+	}
+	s.cfg = cfg
+}
+
 func ceilDiv(a, b int) int {
 	if b <= 0 {
 		return 0
@@ -83,7 +112,7 @@ type segmentResult struct {
 }
 
 // processChunk updates segment state with one VAD result and chunk.
-// chunk must have length cfg.chunkSize (512). Returns Started=true on transition
+// chunk must have length cfg.chunkSize (512, or 256 at 8 kHz). Returns Started=true on transition
 // to speech, Ended=true when segment is finalized with Segment set.
 func (s *segmenter) processChunk(isSpeech bool, chunk []float32) segmentResult {
 	out := segmentResult{}
@@ -91,25 +120,29 @@ func (s *segmenter) processChunk(isSpeech bool, chunk []float32) segmentResult {
 		return out
 	}
 
-	chunkCopy := chunkPool.Get().([]float32)
+	chunkCopy := chunkPool.Get().([]float32)[:s.cfg.chunkSize]
 	copy(chunkCopy, chunk)
 
 	if !s.speechActive {
-		if old := s.preBuffer[s.preBufIdx]; old != nil {
-			chunkPool.Put(old)
-		}
-		s.preBuffer[s.preBufIdx] = chunkCopy
-		s.preBufIdx = (s.preBufIdx + 1) % s.cfg.preChunks
-		if s.preBufCount < s.cfg.preChunks {
-			s.preBufCount++
-		}
 		if isSpeech {
+			// The pre-buffer holds only chunks before the trigger, so the
+			// segment is exactly pre-roll followed by the trigger chunk.
 			s.speechActive = true
 			out.Started = true
 			s.trailingChunks = 0
 			s.sinceTrigger = 1
 			s.segment = s.buildSegmentWithChunk(chunkCopy)
+			chunkPool.Put(chunkCopy)
 			out.Segment = s.segment
+			return out
+		}
+		if old := s.preBuffer[s.preBufIdx]; old != nil {
+			chunkPool.Put(old)
+		}
+		s.preBuffer[s.preBufIdx] = chunkCopy
+		s.preBufIdx = (s.preBufIdx + 1) % s.cfg.preChunks
+		if s.preBufCount < s.cfg.preChunks {
+			s.preBufCount++
 		}
 		return out
 	}
@@ -0,0 +1,119 @@
+package smartturn
+
+import (
+	"testing"
+	"time"
+)
+
+// indexVAD reports speech for chunks whose samples (all equal to the chunk
+// index) fall in [from, to).
+type indexVAD struct{ from, to float32 }
+
+func (v indexVAD) SpeechProbability(frame []float32) (float32, error) {
+	if frame[0] >= v.from && frame[0] < v.to {
+		return 1, nil
+	}
+	return 0, nil
+}
+func (indexVAD) Reset()       {}
+func (indexVAD) Close() error { return nil }
+
+// recordPredictor keeps every segment it is asked to score.
+type recordPredictor struct{ segs []TurnSegment }
+
+func (p *recordPredictor) PredictTurn(seg TurnSegment) (TurnResult, error) {
+	p.segs = append(p.segs, TurnSegment{Audio: append([]float32(nil), seg.Audio...), Start: seg.Start, End: seg.End})
+	return TurnResult{Complete: true, Probability: 1}, nil
+}
+func (*recordPredictor) Close() error { return nil }
+
+// indexedChunks returns n chunks, each filled with its own index.
+func indexedChunks(n, size int) []float32 {
+	audio := make([]float32, n*size)
+	for i := range audio {
+		audio[i] = float32(i / size)
+	}
+	return audio
+}
+
+func testConfig(rate, chunk int) Config {
+	return Config{
+		SampleRate:             rate,
+		ChunkSize:              chunk,
+		Channels:               1,
+		ChannelPolicy:          ChannelAverage,
+		VadThreshold:           0.5,
+		VadPreSpeechMs:         64, // two 32 ms chunks
+		VadStopMs:              64,
+		TurnMaxDurationSeconds: 8,
+		TurnSegmentEmitMs:      32, // one chunk per SegmentReady
+		TurnThreshold:          0.5,
+		TurnTimeoutMs:          1000,
+	}
+}
+
+// TestSegmentPreRoll checks that segments hold each chunk once, starting with
+// the pre-roll, and that their timestamps describe that audio.
+func TestSegmentPreRoll(t *testing.T) {
+	const chunkDur = 32 * time.Millisecond
+	for _, tc := range []struct{ rate, chunk int }{
+		{RequiredSampleRate, RequiredChunkSize},
+		{NarrowbandSampleRate, NarrowbandChunkSize},
+	} {
+		cfg := testConfig(tc.rate, tc.chunk)
+		cfg.VAD = indexVAD{from: 4, to: 8}
+		pred := &recordPredictor{}
+		cfg.TurnPredictor = pred
+		var starts []SpeechStart
+		var segs []SegmentReady
+		e, err := New(cfg, Callbacks{
+			OnSpeechStart: func(ev SpeechStart) { starts = append(starts, ev) },
+			OnSegmentReady: func(ev SegmentReady) {
+				ev.Audio = append([]float32(nil), ev.Audio...)
+				segs = append(segs, ev)
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Start()
+		if err := e.PushSamples(indexedChunks(12, tc.chunk)); err != nil {
+			t.Fatal(err)
+		}
+		e.Close()
+
+		if len(starts) != 1 || starts[0].At != 4*chunkDur || starts[0].PreRollAt != 2*chunkDur {
+			t.Fatalf("%d Hz: SpeechStart = %+v, want At 128ms, PreRollAt 64ms", tc.rate, starts)
+		}
+		// Chunks 2-3 are pre-roll, 4-7 voiced, 8-9 the silence that ends it.
+		if len(segs) != 8 {
+			t.Fatalf("%d Hz: %d SegmentReady events, want 8", tc.rate, len(segs))
+		}
+		for i, ev := range segs {
+			chunk := 2 + i
+			if ev.Start != time.Duration(chunk)*chunkDur || ev.End != time.Duration(chunk+1)*chunkDur {
+				t.Errorf("%d Hz: segment %d spans [%v, %v), want chunk %d", tc.rate, i, ev.Start, ev.End, chunk)
+			}
+			for _, x := range ev.Audio {
+				if x != float32(chunk) {
+					t.Fatalf("%d Hz: segment %d holds chunk %v, want %d", tc.rate, i, x, chunk)
+				}
+			}
+		}
+		if len(pred.segs) != 1 {
+			t.Fatalf("%d Hz: %d predictions, want 1", tc.rate, len(pred.segs))
+		}
+		ts := pred.segs[0]
+		if ts.Start != 2*chunkDur || ts.End != 10*chunkDur || len(ts.Audio) != 8*RequiredChunkSize {
+			t.Errorf("%d Hz: TurnSegment [%v, %v) with %d samples, want [64ms, 320ms) with %d",
+				tc.rate, ts.Start, ts.End, len(ts.Audio), 8*RequiredChunkSize)
+		}
+		if tc.rate == RequiredSampleRate {
+			for i, x := range ts.Audio {
+				if want := float32(2 + i/RequiredChunkSize); x != want {
+					t.Fatalf("TurnSegment sample %d = %v, want %v", i, x, want)
+				}
+			}
+		}
+	}
+}
@@ -1,38 +1,67 @@
 package smartturn
 
 import (
-	"errors"
-	"time"
+	"fmt"
 
 	ort "github.com/yalue/onnxruntime_go"
 )
 
-var errChunkSize = errors.New("chunk must be exactly 512 samples")
-
 const (
-	sileroContextSamples = 64
-	sileroInputSamples   = sileroContextSamples + RequiredChunkSize // 576
-	sileroStateSize      = 2 * 1 * 128
-	sileroResetInterval  = 5 * time.Second
+	sileroStateSize    = 2 * 1 * 128
+	sileroStateVersion = 1 // MarshalBinary layout
+)
+
+// sileroFrame is Silero's input layout at one of its two native rates: each
+// call sees context samples carried over from the previous chunk, then chunk
+// new samples.
+type sileroFrame struct {
+	rate    int // sr input
+	chunk   int
+	context int
+}
+
+var (
+	silero16k = sileroFrame{rate: RequiredSampleRate, chunk: RequiredChunkSize, context: 64}
+	silero8k  = sileroFrame{rate: NarrowbandSampleRate, chunk: NarrowbandChunkSize, context: 32}
 )
 
-// sileroVAD is a stateful ONNX wrapper for Silero VAD. Not safe for concurrent use.
+// sileroFrameFor returns the layout for a valid Config.ChunkSize.
+func sileroFrameFor(chunkSize int) sileroFrame {
+	if chunkSize == NarrowbandChunkSize {
+		return silero8k
+	}
+	return silero16k
+}
+
+func (f sileroFrame) inputSamples() int { return f.context + f.chunk }
+
+// resetSamples is how much audio (5 s) the recurrent state may see before it
+// is cleared. Counting audio rather than wall-clock time keeps results
+// independent of processing speed and reproducible after Restore.
+func (f sileroFrame) resetSamples() int { return 5 * f.rate }
+
+// sileroVAD is a stateful ONNX wrapper for Silero VAD and the default
+// VoiceActivityDetector. The session may be shared (Models); the recurrent
+// state and I/O tensors are per detector. Not safe for concurrent use.
 type sileroVAD struct {
-	session  *ort.AdvancedSession
-	input    *ort.Tensor[float32]   // (1, 576)
-	state    *ort.Tensor[float32]   // (2, 1, 128)
-	sr       *ort.Tensor[int64]     // (1,) = 16000
-	output   *ort.Tensor[float32]   // (1, 1) speech prob
-	stateOut *ort.Tensor[float32]   // (2, 1, 128) new state
-
-	context [sileroContextSamples]float32
-	stateBuf [sileroStateSize]float32
-	lastReset time.Time
+	session  *sharedSession
+	frame    sileroFrame
+	inputs   []ort.Value
+	outputs  []ort.Value
+	input    *ort.Tensor[float32] // (1, context+chunk): 576 at 16 kHz, 288 at 8 kHz
+	state    *ort.Tensor[float32] // (2, 1, 128)
+	sr       *ort.Tensor[int64]   // (1,) = frame.rate
+	output   *ort.Tensor[float32] // (1, 1) speech prob
+	stateOut *ort.Tensor[float32] // (2, 1, 128) new state
+
+	context    []float32 // frame.context
+	stateBuf   [sileroStateSize]float32
+	sinceReset int // samples processed since the last reset
 }
 
-func newSileroVAD(modelPath string) (*sileroVAD, error) {
-	inputShape := ort.NewShape(1, sileroInputSamples)
-	inputData := make([]float32, sileroInputSamples)
+func newSileroVAD(session *sharedSession, frame sileroFrame) (*sileroVAD, error) {
+	inputShape := ort.NewShape(1, int64(frame.inputSamples()))
+	inputData := make([]float32, frame.inputSamples())
 	inputTensor, err := ort.NewTensor(inputShape, inputData)
 	if err != nil {
 		return nil, err
@@ -47,7 +76,7 @@ func newSileroVAD(modelPath string) (*sileroVAD, error) {
 	}
 
 	srShape := ort.NewShape(1)
-	srData := []int64{16000}
+	srData := []int64{int64(frame.rate)}
 	srTensor, err := ort.NewTensor(srShape, srData)
 	if err != nil {
 		_ = inputTensor.Destroy()
@@ -74,34 +103,23 @@ func newSileroVAD(modelPath string) (*sileroVAD, error) {
 		return nil, err
 	}
 
-	sess, err := ort.NewAdvancedSession(modelPath,
-		[]string{"input", "state", "sr"},
-		[]string{"output", "stateN"},
-		[]ort.Value{inputTensor, stateTensor, srTensor},
-		[]ort.Value{outputTensor, stateOutTensor},
-		nil)
-	if err != nil {
-		_ = inputTensor.Destroy()
-		_ = stateTensor.Destroy()
-		_ = srTensor.Destroy()
-		_ = outputTensor.Destroy()
-		_ = stateOutTensor.Destroy()
-		return nil, err
-	}
-
 	v := &sileroVAD{
-		session:   sess,
-		input:     inputTensor,
-		state:     stateTensor,
-		sr:        srTensor,
-		output:    outputTensor,
-		stateOut:  stateOutTensor,
-		lastReset: time.Now(),
+		session:  session,
+		frame:    frame,
+		inputs:   []ort.Value{inputTensor, stateTensor, srTensor},
+		outputs:  []ort.Value{outputTensor, stateOutTensor},
+		input:    inputTensor,
+		state:    stateTensor,
+		sr:       srTensor,
+		output:   outputTensor,
+		stateOut: stateOutTensor,
+		context:  make([]float32, frame.context),
 	}
 	return v, nil
 }
 
-func (v *sileroVAD) resetState() {
+// Reset clears the recurrent state and audio context.
+func (v *sileroVAD) Reset() {
 	for i := range v.context {
 		v.context[i] = 0
 	}
@@ -109,35 +127,36 @@ func (v *sileroVAD) resetState() {
 		v.stateBuf[i] = 0
 	}
 	v.state.ZeroContents()
-	v.lastReset = time.Now()
+	v.sinceReset = 0
 }
 
 func (v *sileroVAD) maybeReset() {
-	if time.Since(v.lastReset) >= sileroResetInterval {
-		v.resetState()
+	if v.sinceReset >= v.frame.resetSamples() {
+		v.Reset()
 	}
+	v.sinceReset += v.frame.chunk
 }
 
-// speechProb returns the speech probability for the given 512-sample chunk.
-// Caller must not modify chunk. No allocations in hot path (reuses session tensors).
-func (v *sileroVAD) speechProb(chunk []float32) (float32, error) {
-	if len(chunk) != RequiredChunkSize {
-		return 0, errChunkSize
+// SpeechProbability returns the speech probability for the given chunk of
+// 512 samples at 16 kHz, or 256 at 8 kHz. Caller must not modify chunk.
+// No allocations in hot path (reuses session tensors).
+func (v *sileroVAD) SpeechProbability(chunk []float32) (float32, error) {
+	if len(chunk) != v.frame.chunk {
+		return 0, ErrChunkSize
 	}
 
 	v.maybeReset()
 
-	// Build input: context (64) + chunk (512) into input tensor
+	// Build input: context (64, or 32 at 8 kHz) + chunk into input tensor
 	inputData := v.input.GetData()
-	copy(inputData[:sileroContextSamples], v.context[:])
-	copy(inputData[sileroContextSamples:], chunk)
+	n := v.frame.context
+	copy(inputData[:n], v.context)
+	copy(inputData[n:], chunk)
 
-	// Update context to last 64 samples of effective input (chunk's last 64 or context+chunk boundary)
-	for i := 0; i < sileroContextSamples; i++ {
-		v.context[i] = inputData[sileroInputSamples-sileroContextSamples+i]
-	}
+	// Update context to the last samples of effective input (chunk's last samples or context+chunk boundary)
+	copy(v.context, inputData[len(inputData)-n:])
 
-	if err := v.session.Run(); err != nil {
+	if err := v.session.run(v.inputs, v.outputs); err != nil {
 		return 0, err
 	}
 
@@ -149,6 +168,54 @@ func (v *sileroVAD) speechProb(chunk []float32) (float32, error) {
 	return prob, nil
 }
 
-func (v *sileroVAD) destroy() error {
-	return v.session.Destroy()
+// MarshalBinary encodes the recurrent state, audio context and reset counter
+// for Engine.Snapshot.
+func (v *sileroVAD) MarshalBinary() ([]byte, error) {
+	return marshalSileroState(v.sinceReset, v.context, v.state.GetData()), nil
+}
+
+// UnmarshalBinary restores state written by MarshalBinary.
+func (v *sileroVAD) UnmarshalBinary(data []byte) error {
+	sinceReset, err := unmarshalSileroState(data, v.context, v.state.GetData())
+	if err != nil {
+		return err
+	}
+	v.sinceReset = sinceReset
+	return nil
+}
+
+// marshalSileroState encodes per-stream Silero state; the batched detector
+// uses the same layout so snapshots move between the two.
+func marshalSileroState(sinceReset int, context, state []float32) []byte {
+	w := &snapWriter{buf: []byte{sileroStateVersion}}
+	w.u32(uint32(sinceReset))
+	w.f32s(context)
+	w.f32s(state)
+	return w.buf
+}
+
+// unmarshalSileroState decodes data into context and state and returns the
+// reset counter. Nothing is written on error.
+func unmarshalSileroState(data []byte, context, state []float32) (int, error) {
+	if len(data) == 0 || data[0] != sileroStateVersion {
+		return 0, fmt.Errorf("%w: unsupported Silero VAD state", ErrSnapshot)
+	}
+	r := &snapReader{buf: data[1:]}
+	sinceReset := int(r.u32())
+	c := r.f32s()
+	st := r.f32s()
+	if r.err == nil && (len(c) != len(context) || len(st) != len(state)) {
+		r.fail("Silero VAD state")
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	copy(context, c)
+	copy(state, st)
+	return sinceReset, nil
+}
+
+// Close destroys the detector's tensors. The session belongs to Models.
+func (v *sileroVAD) Close() error {
+	return destroyValues(v.inputs, v.outputs)
 }
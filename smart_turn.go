@@ -1,27 +1,62 @@
 package smartturn
 
 import (
-	"errors"
+	"fmt"
 
 	ort "github.com/yalue/onnxruntime_go"
+
+	"github.com/cortexswarm/smart-turn-go/features"
 )
 
-var errInvalidSegment = errors.New("invalid segment for Smart-Turn")
+// smartTurnModel identifies the built-in predictor in TurnResult.Metadata.
+const smartTurnModel = "smart-turn-v3.2-cpu"
+
+// Smart-Turn input features: features.SmartTurn(), 80 mels × 800 frames (8 s)
+// at a 160-sample hop.
+const (
+	whisperNMels     = 80
+	whisper8sFrames  = 800
+	whisperHopLength = 160
+)
 
-// smartTurnResult is the structured result from Smart-Turn inference (not exposed to SDK users).
-type smartTurnResult struct {
-	Complete    bool
-	Probability float32
+// newTurnFeatures returns a mel extractor for Smart-Turn input.
+func newTurnFeatures() *features.Extractor {
+	x, err := features.New(features.SmartTurn())
+	if err != nil {
+		panic(err) // the preset is valid
+	}
+	return x
+}
+
+// turnFeatures writes the Smart-Turn input for seg to mel. The engine scores a
+// resumed turn with its earlier audio, so ExtractAt only computes the new
+// frames; that needs every window on one frame grid, so the audio is cut to
+// end on a multiple of the hop in stream position. The cut is under 10 ms of
+// the silence that ended the segment.
+func turnFeatures(x *features.Extractor, seg TurnSegment, mel []float32) error {
+	audio, end := seg.Audio, seg.endSample()
+	if cut := int(end % whisperHopLength); cut < len(audio) {
+		audio, end = audio[:len(audio)-cut], end-int64(cut)
+	}
+	if err := x.ExtractAt(audio, end, mel); err != nil {
+		return &StageError{Stage: StageMel, Err: fmt.Errorf("%w: %w", ErrInvalidSegment, err)}
+	}
+	return nil
 }
 
-// smartTurn runs inference on a finalized speech segment. Unexported; used by engine only.
+// smartTurn runs inference on a finalized speech segment. It is the default
+// TurnPredictor. The session may be shared (Models); the I/O tensors and mel
+// scratch are per predictor. Not safe for concurrent use.
 type smartTurn struct {
-	session *ort.AdvancedSession
+	session *sharedSession
+	mel     *features.Extractor
+	inputs  []ort.Value
+	outputs []ort.Value
 	input   *ort.Tensor[float32]
 	output  *ort.Tensor[float32]
 }
 
-func newSmartTurn(modelPath string) (*smartTurn, error) {
+func newSmartTurn(session *sharedSession) (*smartTurn, error) {
 	// Smart-Turn v3.2 CPU expects input_features shape (1, 80, 800) - Whisper mel for 8s.
 	inputShape := ort.NewShape(1, whisperNMels, whisper8sFrames)
 	inputData := make([]float32, 1*whisperNMels*whisper8sFrames)
@@ -36,39 +71,37 @@ func newSmartTurn(modelPath string) (*smartTurn, error) {
 		_ = inputTensor.Destroy()
 		return nil, err
 	}
-	// Model output is named "logits" (sigmoid probability), not "output"
-	sess, err := ort.NewAdvancedSession(modelPath,
-		[]string{"input_features"},
-		[]string{"logits"},
-		[]ort.Value{inputTensor},
-		[]ort.Value{outputTensor},
-		nil)
-	if err != nil {
-		_ = inputTensor.Destroy()
-		_ = outputTensor.Destroy()
-		return nil, err
-	}
-	return &smartTurn{session: sess, input: inputTensor, output: outputTensor}, nil
+	return &smartTurn{
+		session: session,
+		mel:     newTurnFeatures(),
+		inputs:  []ort.Value{inputTensor},
+		outputs: []ort.Value{outputTensor},
+		input:   inputTensor,
+		output:  outputTensor,
+	}, nil
 }
 
-// run runs Smart-Turn on the segment audio. Segment is truncated to last 8s or left-padded to 8s.
-func (st *smartTurn) run(segment []float32) (smartTurnResult, error) {
-	mel := computeWhisperMel(segment)
-	if mel == nil {
-		return smartTurnResult{}, errInvalidSegment
+// PredictTurn runs Smart-Turn on the segment audio. Segment is truncated to last 8s or left-padded to 8s.
+func (st *smartTurn) PredictTurn(seg TurnSegment) (TurnResult, error) {
+	if err := turnFeatures(st.mel, seg, st.input.GetData()); err != nil {
+		return TurnResult{}, err
 	}
-	inputData := st.input.GetData()
-	copy(inputData, mel)
-	if err := st.session.Run(); err != nil {
-		return smartTurnResult{}, err
+	if err := st.session.run(st.inputs, st.outputs); err != nil {
+		return TurnResult{}, err
 	}
-	prob := st.output.GetData()[0]
-	return smartTurnResult{
+	return smartTurnResult(st.output.GetData()[0]), nil
+}
+
+// smartTurnResult wraps a Smart-Turn probability as a TurnResult.
+func smartTurnResult(prob float32) TurnResult {
+	return TurnResult{
 		Complete:    prob > 0.5,
 		Probability: prob,
-	}, nil
+		Metadata:    map[string]string{"model": smartTurnModel},
+	}
 }
 
-func (st *smartTurn) destroy() error {
-	return st.session.Destroy()
+// Close destroys the predictor's tensors. The session belongs to Models.
+func (st *smartTurn) Close() error {
+	return destroyValues(st.inputs, st.outputs)
 }
@@ -0,0 +1,144 @@
+package smartturn
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// goldenTurnCases are the signals of features/testdata with their lengths in
+// samples; goldenTurnSignal rebuilds them like goldenSignal in
+// features/features_test.go.
+var goldenTurnCases = []struct {
+	name string
+	n    int
+}{
+	{"short", 40000},
+	{"exact", 128000},
+	{"long", 160000},
+	{"silence", 48000},
+}
+
+func goldenTurnSignal(name string, n int) []float32 {
+	x := make([]float32, n)
+	if name == "silence" {
+		return x
+	}
+	state := uint32(12345)
+	for i := range x {
+		state = (state*1103515245 + 12345) & (1<<31 - 1)
+		noise := int(state>>16)%129 - 64
+		saw := (i*3)%73 - 36
+		square := -200
+		if (i/57)%2 == 1 {
+			square = 200
+		}
+		env := 1 + (i/4000)%4
+		x[i] = float32(env*(saw*8+square+noise)) / 16384
+	}
+	return x
+}
+
+func readGoldenMel(t *testing.T, name string) []float32 {
+	t.Helper()
+	f, err := os.Open(filepath.Join("features", "testdata", name+".f32.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mel := make([]float32, len(data)/4)
+	for i := range mel {
+		mel[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return mel
+}
+
+// trailingSilenceSegment returns the golden signal followed by cut samples of
+// silence, ending at a stream position cut samples past the hop grid.
+func trailingSilenceSegment(name string, n, cut int) TurnSegment {
+	audio := append(goldenTurnSignal(name, n), make([]float32, cut)...)
+	end := int64(whisperHopLength*10007 + cut)
+	return segmentAt(audio, end)
+}
+
+func segmentAt(audio []float32, end int64) TurnSegment {
+	return TurnSegment{
+		Audio: audio,
+		Start: time.Duration(end-int64(len(audio))) * time.Second / RequiredSampleRate,
+		End:   time.Duration(end) * time.Second / RequiredSampleRate,
+	}
+}
+
+// TestTurnFeaturesTrim appends up to 159 samples of silence past the hop
+// grid: turnFeatures cuts exactly those, so the window is the golden one.
+func TestTurnFeaturesTrim(t *testing.T) {
+	x := newTurnFeatures()
+	mel := make([]float32, x.Size())
+	for _, c := range goldenTurnCases {
+		want := readGoldenMel(t, c.name)
+		for _, cut := range []int{0, 1, 80, whisperHopLength - 1} {
+			if err := turnFeatures(x, trailingSilenceSegment(c.name, c.n, cut), mel); err != nil {
+				t.Fatal(err)
+			}
+			var d float64
+			for i := range mel {
+				d = math.Max(d, math.Abs(float64(mel[i])-float64(want[i])))
+			}
+			if d > 1e-5 {
+				t.Errorf("%s with %d trailing samples: max difference %g from the golden window", c.name, cut, d)
+			}
+		}
+	}
+}
+
+// TestTurnTrimKeepsDecision scores each golden signal with up to 159 samples
+// of trailing silence twice: ending on the hop grid, so nothing is cut, and
+// ending past it, so turnFeatures cuts the silence. Smart-Turn must reach the
+// same decision. It needs the model: set SMART_TURN_MODEL_PATH and, if ONNX
+// Runtime is not on the default path, ONNXRUNTIME_SHARED_LIBRARY_PATH.
+func TestTurnTrimKeepsDecision(t *testing.T) {
+	path := os.Getenv("SMART_TURN_MODEL_PATH")
+	if path == "" {
+		t.Skip("SMART_TURN_MODEL_PATH not set")
+	}
+	models, err := LoadModels(ModelsConfig{SmartTurnModelPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer models.Close()
+	st, err := newSmartTurn(models.turn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	for _, c := range goldenTurnCases {
+		for _, cut := range []int{1, 80, whisperHopLength - 1} {
+			seg := trailingSilenceSegment(c.name, c.n, cut)
+			whole, err := st.PredictTurn(segmentAt(seg.Audio, int64(whisperHopLength*20011)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			trimmed, err := st.PredictTurn(seg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if whole.Complete != trimmed.Complete {
+				t.Errorf("%s with %d trailing samples: complete %v (p=%.4f) untrimmed, %v (p=%.4f) trimmed",
+					c.name, cut, whole.Complete, whole.Probability, trimmed.Complete, trimmed.Probability)
+			}
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package smartturn
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Snapshot format: the magic, a little-endian uint16 version, then the fields
+// written by Engine.Snapshot in order. Bump snapshotVersion on any layout change.
+const (
+	snapshotMagic   = "STSN"
+	snapshotVersion = 2
+)
+
+// ErrSnapshot is returned by Restore for data that is not a snapshot, has an
+// unsupported version, or was taken with an incompatible Config.
+var ErrSnapshot = errors.New("invalid snapshot")
+
+// Snapshot serializes the engine's streaming state so another engine, possibly
+// in another process, can continue the stream with Restore: the VAD state, the
+// segmenter pre-buffer and open segment, the pending turn's counters and audio,
+// emit offsets, stream time, and the samples buffered by PushSamples, the
+// downmixer and the resampler. Models and callbacks are not included.
+//
+// With Config.AsyncTurnPrediction, Snapshot first waits for an in-flight
+// prediction and delivers its events, as Flush does. A VoiceActivityDetector
+// is snapshotted if it implements encoding.BinaryMarshaler; otherwise it is
+// treated as stateless. Both built-in detectors implement it.
+func (e *Engine) Snapshot() ([]byte, error) {
+	if e.closed {
+		return nil, ErrClosed
+	}
+	e.awaitPrediction()
+
+	w := &snapWriter{buf: []byte(snapshotMagic)}
+	w.u16(snapshotVersion)
+	w.u32(uint32(e.cfg.SampleRate))
+	w.u32(uint32(e.cfg.ChunkSize))
+	w.u32(uint32(e.cfg.Channels))
+	w.u32(uint32(e.cfg.ChannelPolicy))
+
+	w.bool(e.listening)
+	w.f32s(e.pending[:e.pendingLen])
+	w.i64(e.streamPos)
+	w.i64(e.segmentStart)
+	w.i64(e.lastVoicedEnd)
+	w.u32(uint32(e.segmentEmittedSoFar))
+	w.bool(e.turnPending)
+	w.u32(uint32(e.turnPendingSilenceChunks))
+	w.f32s(e.turnAudio)
+	w.f32(e.lastVADProb)
+	w.f32(e.lastTurnProb)
+
+	e.segmenter.marshal(w)
+	if e.mixer != nil {
+		e.mixer.marshal(w)
+	}
+	if e.resampler != nil {
+		e.resampler.marshal(w)
+	}
+
+	m, ok := e.vad.(encoding.BinaryMarshaler)
+	w.bool(ok)
+	if ok {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return nil, stageError(StageVAD, err)
+		}
+		w.bytes(b)
+	}
+	return w.buf, nil
+}
+
+// Restore replaces the engine's streaming state with a Snapshot. The engine
+// must have been created with the same SampleRate, ChunkSize, Channels and
+// ChannelPolicy; other Config fields may differ and take effect from the next
+// chunk. On error the engine is left unchanged, except that a detector whose
+// UnmarshalBinary fails may need Reset. Restore emits no events and discards
+// any in-flight async prediction. A detector without snapshotted state is Reset.
+func (e *Engine) Restore(data []byte) error {
+	if e.closed {
+		return ErrClosed
+	}
+	r := &snapReader{buf: data}
+	if string(r.next(len(snapshotMagic))) != snapshotMagic {
+		return fmt.Errorf("%w: bad magic", ErrSnapshot)
+	}
+	if v := r.u16(); r.err == nil && v != snapshotVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrSnapshot, v)
+	}
+	rate, chunk, channels, policy := r.u32(), r.u32(), r.u32(), r.u32()
+	if r.err == nil && (int(rate) != e.cfg.SampleRate || int(chunk) != e.cfg.ChunkSize ||
+		int(channels) != e.cfg.Channels || ChannelPolicy(policy) != e.cfg.ChannelPolicy) {
+		return fmt.Errorf("%w: taken at %d Hz, chunk %d, %d channels, %v; engine has %d Hz, chunk %d, %d channels, %v",
+			ErrSnapshot, rate, chunk, channels, ChannelPolicy(policy),
+			e.cfg.SampleRate, e.cfg.ChunkSize, e.cfg.Channels, e.cfg.ChannelPolicy)
+	}
+
+	listening := r.bool()
+	pending := r.f32s()
+	streamPos := r.i64()
+	segmentStart := r.i64()
+	lastVoicedEnd := r.i64()
+	emitted := int(r.u32())
+	turnPending := r.bool()
+	silenceChunks := int(r.u32())
+	turnAudio := r.f32s()
+	lastVADProb := r.f32()
+	lastTurnProb := r.f32()
+	if len(pending) >= len(e.pending) {
+		r.fail("pending samples")
+	}
+	if len(turnAudio) >= 2*e.turnContext() {
+		r.fail("turn audio")
+	}
+
+	seg := newSegmenter(e.rate, e.cfg.ChunkSize, e.cfg.VadPreSpeechMs, e.cfg.VadStopMs, e.cfg.TurnMaxDurationSeconds)
+	seg.unmarshal(r)
+	var mixer *downmixer
+	if e.mixer != nil {
+		mixer = newDownmixer(e.cfg.Channels, e.cfg.ChannelPolicy, e.cfg.Channel, e.cfg.SampleRate)
+		mixer.unmarshal(r)
+	}
+	var rs *resampler
+	if e.resampler != nil {
+		rs = newResampler(e.cfg.SampleRate, e.rate)
+		rs.unmarshal(r)
+	}
+	hasVAD := r.bool()
+	var vadState []byte
+	if hasVAD {
+		vadState = r.bytes()
+	}
+	if r.err == nil && len(r.buf) != 0 {
+		r.fail("trailing data")
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	if hasVAD {
+		u, ok := e.vad.(encoding.BinaryUnmarshaler)
+		if !ok {
+			return fmt.Errorf("%w: detector cannot restore state", ErrSnapshot)
+		}
+		if err := u.UnmarshalBinary(vadState); err != nil {
+			return stageError(StageVAD, err)
+		}
+	} else {
+		e.vad.Reset()
+	}
+
+	e.segmenter.reset()
+	e.segmenter = seg
+	if mixer != nil {
+		e.mixer = mixer
+	}
+	if rs != nil {
+		e.resampler = rs
+	}
+	e.listening = listening
+	e.pendingLen = copy(e.pending, pending)
+	e.streamPos = streamPos
+	e.segmentStart = segmentStart
+	e.lastVoicedEnd = lastVoicedEnd
+	e.segmentEmittedSoFar = emitted
+	e.turnPending = turnPending
+	e.turnPendingSilenceChunks = silenceChunks
+	e.turnAudio = append(e.turnAudio[:0], turnAudio...)
+	e.lastVADProb = lastVADProb
+	e.lastTurnProb = lastTurnProb
+	e.cancelPrediction()
+	return nil
+}
+
+// marshal writes the pre-buffer (oldest chunk first) and the open segment.
+func (s *segmenter) marshal(w *snapWriter) {
+	w.bool(s.speechActive)
+	w.u32(uint32(s.trailingChunks))
+	w.u32(uint32(s.sinceTrigger))
+	w.u32(uint32(s.preBufCount))
+	startIdx := (s.preBufIdx - s.preBufCount + s.cfg.preChunks) % s.cfg.preChunks
+	for i := 0; i < s.preBufCount; i++ {
+		w.f32s(s.preBuffer[(startIdx+i)%s.cfg.preChunks])
+	}
+	w.f32s(s.segment)
+}
+
+// unmarshal reads what marshal wrote into a fresh segmenter. If the snapshot
+// kept more pre-buffer chunks than VadPreSpeechMs now allows, the oldest are dropped.
+func (s *segmenter) unmarshal(r *snapReader) {
+	s.speechActive = r.bool()
+	s.trailingChunks = int(r.u32())
+	s.sinceTrigger = int(r.u32())
+	count := int(r.u32())
+	for i := 0; i < count && r.err == nil; i++ {
+		chunk := r.f32s()
+		if r.err == nil && len(chunk) != s.cfg.chunkSize {
+			r.fail("pre-buffer chunk")
+		}
+		if r.err != nil || i < count-s.cfg.preChunks {
+			continue
+		}
+		buf := chunkPool.Get().([]float32)[:s.cfg.chunkSize]
+		copy(buf, chunk)
+		s.preBuffer[s.preBufIdx] = buf
+		s.preBufIdx = (s.preBufIdx + 1) % s.cfg.preChunks
+		s.preBufCount++
+	}
+	if segment := r.f32s(); len(segment) > 0 {
+		s.segment = segment
+	}
+}
+
+func (d *downmixer) marshal(w *snapWriter) {
+	w.f32s(d.partial[:d.partialLen])
+	w.u32(uint32(d.channel))
+	w.f64s(d.energy)
+	w.u32(uint32(d.untilDecide))
+}
+
+func (d *downmixer) unmarshal(r *snapReader) {
+	partial := r.f32s()
+	channel := int(r.u32())
+	energy := r.f64s()
+	untilDecide := int(r.u32())
+	if r.err != nil {
+		return
+	}
+	if len(partial) >= d.channels || len(energy) != len(d.energy) || channel >= d.channels {
+		r.fail("downmixer")
+		return
+	}
+	d.partialLen = copy(d.partial, partial)
+	if d.policy == ChannelLoudest {
+		d.channel = channel
+		copy(d.energy, energy)
+		d.untilDecide = untilDecide
+	}
+}
+
+func (rs *resampler) marshal(w *snapWriter) {
+	w.f32s(rs.hist)
+	w.u32(uint32(rs.idx))
+	w.u32(uint32(rs.phase))
+}
+
+func (rs *resampler) unmarshal(r *snapReader) {
+	hist := r.f32s()
+	idx := int(r.u32())
+	phase := int(r.u32())
+	if r.err != nil {
+		return
+	}
+	if phase >= rs.up || idx < rs.half-1 || idx > len(hist)+rs.half {
+		r.fail("resampler")
+		return
+	}
+	rs.hist = append(rs.hist[:0], hist...)
+	rs.idx = idx
+	rs.phase = phase
+}
+
+// snapWriter appends little-endian values to buf.
+type snapWriter struct {
+	buf []byte
+}
+
+func (w *snapWriter) u16(v uint16)  { w.buf = binary.LittleEndian.AppendUint16(w.buf, v) }
+func (w *snapWriter) u32(v uint32)  { w.buf = binary.LittleEndian.AppendUint32(w.buf, v) }
+func (w *snapWriter) i64(v int64)   { w.buf = binary.LittleEndian.AppendUint64(w.buf, uint64(v)) }
+func (w *snapWriter) f32(v float32) { w.u32(math.Float32bits(v)) }
+
+func (w *snapWriter) bool(v bool) {
+	if v {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *snapWriter) bytes(b []byte) {
+	w.u32(uint32(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *snapWriter) f32s(s []float32) {
+	w.u32(uint32(len(s)))
+	for _, v := range s {
+		w.f32(v)
+	}
+}
+
+func (w *snapWriter) f64s(s []float64) {
+	w.u32(uint32(len(s)))
+	for _, v := range s {
+		w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(v))
+	}
+}
+
+// snapReader consumes what snapWriter wrote. The first failure sticks in err;
+// later reads return zero values.
+type snapReader struct {
+	buf []byte
+	err error
+}
+
+func (r *snapReader) fail(what string) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: corrupt %s", ErrSnapshot, what)
+	}
+}
+
+func (r *snapReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.fail("data (truncated)")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *snapReader) u16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *snapReader) u32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *snapReader) i64() int64 {
+	if b := r.next(8); b != nil {
+		return int64(binary.LittleEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (r *snapReader) f32() float32 { return math.Float32frombits(r.u32()) }
+
+func (r *snapReader) bool() bool {
+	b := r.next(1)
+	return b != nil && b[0] != 0
+}
+
+func (r *snapReader) bytes() []byte {
+	return r.next(int(r.u32()))
+}
+
+func (r *snapReader) f32s() []float32 {
+	b := r.next(4 * int(r.u32()))
+	if b == nil {
+		return nil
+	}
+	s := make([]float32, len(b)/4)
+	for i := range s {
+		s[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return s
+}
+
+func (r *snapReader) f64s() []float64 {
+	b := r.next(8 * int(r.u32()))
+	if b == nil {
+		return nil
+	}
+	s := make([]float64, len(b)/8)
+	for i := range s {
+		s[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+package smartturn
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// durationPredictor completes a turn once the scored audio lasts at least
+// min; shorter turns stay pending.
+type durationPredictor struct{ min time.Duration }
+
+func (p durationPredictor) PredictTurn(seg TurnSegment) (TurnResult, error) {
+	if seg.End-seg.Start >= p.min {
+		return TurnResult{Complete: true, Probability: 0.9}, nil
+	}
+	return TurnResult{Probability: 0.2}, nil
+}
+func (durationPredictor) Close() error { return nil }
+
+// snapshotStream is stereo audio at rate: channel 0 carries a faint noise
+// floor, channel 1 the same floor plus voice in the given spans (seconds).
+func snapshotStream(rate int, seconds float64, spans [][2]float64) []float32 {
+	n := int(seconds * float64(rate))
+	left, right := noise(1, n, 0.002), noise(2, n, 0.002)
+	for _, s := range spans {
+		from, to := int(s[0]*float64(rate)), int(s[1]*float64(rate))
+		for i, v := range voice(rate, to-from, 0.3) {
+			right[from+i] += v
+		}
+	}
+	out := make([]float32, 2*n)
+	for i := range left {
+		out[2*i], out[2*i+1] = left[i], right[i]
+	}
+	return out
+}
+
+func newSnapshotEngine(t *testing.T, rate int, events *[]Event) *Engine {
+	t.Helper()
+	cfg := testConfig(rate, RequiredChunkSize)
+	cfg.Channels = 2
+	cfg.ChannelPolicy = ChannelLoudest
+	vad, err := NewEnergyVAD(DefaultEnergyVADConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.VAD = vad
+	cfg.TurnPredictor = durationPredictor{min: 1500 * time.Millisecond}
+	e, err := New(cfg, recordEvents(events))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Start()
+	return e
+}
+
+// pushPieces pushes audio in pieces of an odd number of samples, so frames,
+// chunks and resampler input are split at arbitrary points.
+func pushPieces(t *testing.T, e *Engine, audio []float32) {
+	t.Helper()
+	for len(audio) > 0 {
+		n := min(3001, len(audio))
+		if err := e.PushSamples(audio[:n]); err != nil {
+			t.Fatal(err)
+		}
+		audio = audio[n:]
+	}
+}
+
+// TestSnapshotRoundTrip cuts a stream mid-segment and mid-pending-turn,
+// restores the snapshot into a fresh engine, and expects the same events as
+// an uninterrupted engine.
+func TestSnapshotRoundTrip(t *testing.T) {
+	// A turn with a pause (pending, then complete), a short turn that stays
+	// pending until TurnTimeoutMs, and speech just after that timeout.
+	spans := [][2]float64{{1, 2.2}, {2.6, 3.4}, {5, 5.6}, {6.9, 7.5}}
+	for _, rate := range []int{16000, 44100, 48000} {
+		stream := snapshotStream(rate, 9, spans)
+		var want []Event
+		ref := newSnapshotEngine(t, rate, &want)
+		pushPieces(t, ref, stream)
+		if err := ref.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		ref.Close()
+		var pending, complete bool
+		for _, ev := range want {
+			if tp, ok := ev.(TurnPrediction); ok {
+				pending = pending || !tp.Complete
+				complete = complete || tp.Complete
+			}
+		}
+		if !pending || !complete {
+			t.Fatalf("%d Hz: reference events %v lack a pending or a completed turn", rate, want)
+		}
+
+		for _, cut := range []float64{1.6, 2.4, 6} { // mid-segment, pending, awaiting timeout
+			// An odd sample index leaves half a stereo frame in the downmixer.
+			at := 2*int(cut*float64(rate)) + 1
+			var got []Event
+			a := newSnapshotEngine(t, rate, &got)
+			pushPieces(t, a, stream[:at])
+			snap, err := a.Snapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			a.Close()
+			b := newSnapshotEngine(t, rate, &got)
+			got = got[:len(got)-1] // b's ListeningStarted
+			if err := b.Restore(snap); err != nil {
+				t.Fatal(err)
+			}
+			pushPieces(t, b, stream[at:])
+			if err := b.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			b.Close()
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%d Hz, cut at %vs: restored events differ\n got %v\nwant %v", rate, cut, got, want)
+			}
+		}
+	}
+}
+
+func TestRestoreRejectsInvalid(t *testing.T) {
+	var events []Event
+	e := newSnapshotEngine(t, 16000, &events)
+	defer e.Close()
+	pushPieces(t, e, snapshotStream(16000, 1.5, [][2]float64{{1, 1.5}}))
+	snap, err := e.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badMagic := append([]byte("XXXX"), snap[4:]...)
+	badVersion := append([]byte(nil), snap...)
+	binary.LittleEndian.PutUint16(badVersion[4:], snapshotVersion+1)
+	cases := map[string][]byte{
+		"empty":     nil,
+		"magic":     badMagic,
+		"version":   badVersion,
+		"truncated": snap[:len(snap)-1],
+		"header":    snap[:8],
+		"trailing":  append(append([]byte(nil), snap...), 0),
+	}
+	for name, data := range cases {
+		if err := e.Restore(data); !errors.Is(err, ErrSnapshot) {
+			t.Errorf("%s: Restore = %v, want ErrSnapshot", name, err)
+		}
+	}
+	for i := range snap {
+		if err := e.Restore(snap[:i]); !errors.Is(err, ErrSnapshot) {
+			t.Fatalf("truncated to %d bytes: Restore = %v, want ErrSnapshot", i, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"SampleRate", func(c *Config) { c.SampleRate = 48000 }},
+		{"ChunkSize", func(c *Config) { c.SampleRate, c.ChunkSize = NarrowbandSampleRate, NarrowbandChunkSize }},
+		{"Channels", func(c *Config) { c.Channels = 1 }},
+	} {
+		cfg := testConfig(16000, RequiredChunkSize)
+		cfg.Channels = 2
+		cfg.ChannelPolicy = ChannelLoudest
+		cfg.VAD, cfg.TurnPredictor = indexVAD{}, &recordPredictor{}
+		tc.modify(&cfg)
+		other, err := New(cfg, Callbacks{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := other.Restore(snap); !errors.Is(err, ErrSnapshot) {
+			t.Errorf("%s mismatch: Restore = %v, want ErrSnapshot", tc.name, err)
+		}
+		other.Close()
+	}
+
+	// A rejected snapshot leaves the engine usable: the valid one still restores.
+	if err := e.Restore(snap); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,44 @@
+package smartturn
+
+import "time"
+
+// State is a point-in-time snapshot of an Engine, returned by Engine.State.
+// Durations are stream time (see Callbacks).
+type State struct {
+	Listening bool // between Start and Stop
+	InSpeech  bool // a VAD segment is open (after SpeechStart or a resumed pending turn)
+
+	// TurnPending is true after a segment ended without completing the turn:
+	// the turn prediction was below TurnThreshold, failed, or (Predicting) is
+	// still in flight. It clears on SpeechEnd.
+	TurnPending bool
+	Predicting  bool // an async turn prediction is in flight
+
+	SegmentDuration time.Duration // length of the open segment including pre-roll; 0 when not InSpeech
+	TrailingSilence time.Duration // time since the end of the last voiced chunk
+
+	LastVADProbability  float32 // speech probability of the most recent chunk
+	LastTurnProbability float32 // probability of the most recent turn prediction; 0 before the first
+
+	StreamTime time.Duration // end of the last processed chunk
+}
+
+// State returns a snapshot of the engine's listening, speech and turn status.
+// Like every Engine method it must not race with PushPCM; use Worker.State
+// from other goroutines.
+func (e *Engine) State() State {
+	st := State{
+		Listening:           e.listening,
+		InSpeech:            e.segmenter.speechActive,
+		TurnPending:         e.turnPending,
+		Predicting:          e.predicting,
+		TrailingSilence:     e.streamTime(e.streamPos - e.lastVoicedEnd),
+		LastVADProbability:  e.lastVADProb,
+		LastTurnProbability: e.lastTurnProb,
+		StreamTime:          e.streamTime(e.streamPos),
+	}
+	if st.InSpeech {
+		st.SegmentDuration = e.streamTime(int64(len(e.segmenter.segment)))
+	}
+	return st
+}
@@ -0,0 +1,45 @@
+package smartturn
+
+import "time"
+
+// TurnPredictor decides whether a finished speech segment completes the
+// speaker's turn. Smart-Turn v3.2 (Config.SmartTurnModelPath) is the default;
+// set Config.TurnPredictor to use a newer model, a remote service stand-in, a
+// text-aware scorer or a test fake.
+//
+// The engine calls PredictTurn from one goroutine at a time: the goroutine
+// pushing audio, or the background worker with Config.AsyncTurnPrediction.
+type TurnPredictor interface {
+	// PredictTurn scores seg. seg.Audio must not be modified or retained after
+	// PredictTurn returns.
+	PredictTurn(seg TurnSegment) (TurnResult, error)
+	// Close releases resources. The engine calls it from Engine.Close.
+	Close() error
+}
+
+// turnWindowSeconds is how much of a pending turn's earlier audio a
+// TurnSegment carries at most: Smart-Turn's input window.
+const turnWindowSeconds = 8
+
+// TurnSegment is the audio of a segment that ended in VAD silence. When the
+// turn was left pending by an earlier prediction, Audio starts with the turn's
+// earlier audio (segments and the pauses between them), keeping about the last 8 s.
+type TurnSegment struct {
+	Audio      []float32     // 16 kHz mono, including pre-roll (upsampled in 8 kHz mode)
+	Start, End time.Duration // stream time bounds of Audio
+}
+
+// endSample returns the stream position (in 16 kHz samples) where Audio ends.
+func (s TurnSegment) endSample() int64 {
+	return int64(s.End * RequiredSampleRate / time.Second)
+}
+
+// TurnResult is a TurnPredictor's verdict. The engine ends the turn when
+// Probability >= Config.TurnThreshold.
+type TurnResult struct {
+	Complete    bool    // the predictor's own decision
+	Probability float32 // probability that the turn is complete, in [0, 1]
+	// Metadata carries optional predictor-specific details (model name,
+	// version, ...). It is passed through on TurnPrediction.
+	Metadata map[string]string
+}
@@ -0,0 +1,112 @@
+package smartturn
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/cortexswarm/smart-turn-go/features"
+)
+
+// scriptVAD reports speech for the chunks speech selects, counting chunks
+// from the first call.
+type scriptVAD struct {
+	speech func(chunk int) bool
+	n      int
+}
+
+func (v *scriptVAD) SpeechProbability([]float32) (float32, error) {
+	v.n++
+	if v.speech(v.n - 1) {
+		return 1, nil
+	}
+	return 0, nil
+}
+func (v *scriptVAD) Reset()     { v.n = 0 }
+func (*scriptVAD) Close() error { return nil }
+
+// melPredictor computes Smart-Turn features as the built-in predictors do,
+// records each segment with the extractor's cache counts for it, and never
+// completes the turn.
+type melPredictor struct {
+	mel   *features.Extractor
+	buf   []float32
+	segs  []TurnSegment
+	stats []features.CacheStats
+}
+
+func (p *melPredictor) PredictTurn(seg TurnSegment) (TurnResult, error) {
+	before := p.mel.CacheStats()
+	if err := turnFeatures(p.mel, seg, p.buf); err != nil {
+		return TurnResult{}, err
+	}
+	after := p.mel.CacheStats()
+	p.segs = append(p.segs, TurnSegment{Start: seg.Start, End: seg.End})
+	p.stats = append(p.stats, features.CacheStats{
+		Computed: after.Computed - before.Computed,
+		Reused:   after.Reused - before.Reused,
+	})
+	return TurnResult{}, nil
+}
+func (*melPredictor) Close() error { return nil }
+
+// TestResumedTurnReusesFrames scores a turn that resumes after an incomplete
+// prediction: the second prediction must cover the first segment too and
+// reuse its cached mel frames.
+func TestResumedTurnReusesFrames(t *testing.T) {
+	for _, tc := range []struct {
+		rate, chunk int
+		async       bool
+	}{
+		{RequiredSampleRate, RequiredChunkSize, false},
+		{RequiredSampleRate, RequiredChunkSize, true},
+		{NarrowbandSampleRate, NarrowbandChunkSize, false},
+	} {
+		cfg := testConfig(tc.rate, tc.chunk)
+		cfg.AsyncTurnPrediction = tc.async
+		// Speech in chunks 10-49 and, after a pause shorter than
+		// TurnTimeoutMs, in chunks 60-89.
+		cfg.VAD = &scriptVAD{speech: func(i int) bool { return i >= 10 && i < 50 || i >= 60 && i < 90 }}
+		x := newTurnFeatures()
+		pred := &melPredictor{mel: x, buf: make([]float32, x.Size())}
+		cfg.TurnPredictor = pred
+		var preds []TurnPrediction
+		e, err := New(cfg, Callbacks{OnTurnPrediction: func(ev TurnPrediction) { preds = append(preds, ev) }})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Start()
+		rng := rand.New(rand.NewSource(1))
+		audio := make([]float32, 100*tc.chunk)
+		for i := range audio {
+			audio[i] = 0.3 * (2*rng.Float32() - 1)
+		}
+		// Flush in the pause waits for an async first prediction, which
+		// would otherwise be skipped as stale once speech resumes.
+		for _, part := range [][]float32{audio[:55*tc.chunk], audio[55*tc.chunk:]} {
+			if err := e.PushSamples(part); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		e.Close()
+
+		if len(pred.segs) != 2 {
+			t.Fatalf("%d Hz: %d predictions, want 2", tc.rate, len(pred.segs))
+		}
+		first, second := pred.segs[0], pred.segs[1]
+		if second.Start != first.Start {
+			t.Errorf("%d Hz: resumed turn scored from %v, want the first segment's start %v", tc.rate, second.Start, first.Start)
+		}
+		if n := len(preds); n == 0 || preds[n-1].Start != second.Start {
+			t.Errorf("%d Hz: TurnPrediction events %+v, want the last to start at %v", tc.rate, preds, second.Start)
+		}
+		// All of the first prediction's frames are reused, except at 8 kHz
+		// the few its upsampled end touched.
+		computed, reused := pred.stats[0].Computed, pred.stats[1].Reused
+		if computed == 0 || reused+3 < computed {
+			t.Errorf("%d Hz: first prediction computed %d frames, second reused %d", tc.rate, computed, reused)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package smartturn
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type turnJob struct {
+	gen uint64
+	seg TurnSegment
+}
+
+type turnOutcome struct {
+	gen uint64
+	res TurnResult
+	err error
+}
+
+// turnWorker runs the TurnPredictor on its own goroutine so the engine keeps
+// consuming audio while a prediction is computed (Config.AsyncTurnPrediction).
+// The engine submits ended segments and polls outcomes from its own goroutine;
+// outcomes carry the generation they were submitted with so stale ones can be
+// discarded.
+//
+// Only the newest segment matters, so submit never blocks: the job waits in a
+// single slot that a later submit overwrites. The engine publishes its current
+// generation in gen, and the worker skips a job, or drops its outcome, once the
+// generation has moved on.
+type turnWorker struct {
+	tp  TurnPredictor
+	gen atomic.Uint64 // the engine's predictGen
+
+	mu      sync.Mutex
+	job     turnJob
+	hasJob  bool
+	wake    chan struct{} // buffered 1: a job is waiting
+	outcome chan turnOutcome
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+func newTurnWorker(tp TurnPredictor) *turnWorker {
+	w := &turnWorker{
+		tp:      tp,
+		wake:    make(chan struct{}, 1),
+		outcome: make(chan turnOutcome, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+func (w *turnWorker) loop() {
+	defer close(w.done)
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-w.wake:
+		}
+		w.mu.Lock()
+		job, ok := w.job, w.hasJob
+		w.job, w.hasJob = turnJob{}, false
+		w.mu.Unlock()
+		if !ok || job.gen != w.gen.Load() {
+			continue
+		}
+		r, err := w.tp.PredictTurn(job.seg)
+		if job.gen != w.gen.Load() {
+			continue
+		}
+		select {
+		case w.outcome <- turnOutcome{gen: job.gen, res: r, err: err}:
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// submit makes seg the job for generation gen, replacing a job the worker has
+// not started, and never blocks. The worker takes ownership of seg.Audio.
+func (w *turnWorker) submit(gen uint64, seg TurnSegment) {
+	w.gen.Store(gen)
+	w.mu.Lock()
+	w.job, w.hasJob = turnJob{gen: gen, seg: seg}, true
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// invalidate makes gen current, so earlier jobs are skipped, and discards an
+// outcome already waiting.
+func (w *turnWorker) invalidate(gen uint64) {
+	w.gen.Store(gen)
+	select {
+	case <-w.outcome:
+	default:
+	}
+}
+
+// stop terminates the goroutine and waits for it, abandoning a waiting job.
+func (w *turnWorker) stop() {
+	close(w.quit)
+	<-w.done
+}
@@ -0,0 +1,191 @@
+package smartturn
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// levelVAD reports speech for chunks whose first sample is nonzero.
+type levelVAD struct{}
+
+func (levelVAD) SpeechProbability(frame []float32) (float32, error) {
+	if frame[0] != 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+func (levelVAD) Reset()       {}
+func (levelVAD) Close() error { return nil }
+
+// slowPredictor takes delay per prediction, completes every turn, and records
+// the end of each segment it scored.
+type slowPredictor struct {
+	delay time.Duration
+	mu    sync.Mutex
+	ends  []time.Duration
+}
+
+func (p *slowPredictor) PredictTurn(seg TurnSegment) (TurnResult, error) {
+	time.Sleep(p.delay)
+	p.mu.Lock()
+	p.ends = append(p.ends, seg.End)
+	p.mu.Unlock()
+	return TurnResult{Complete: true, Probability: 1}, nil
+}
+func (*slowPredictor) Close() error { return nil }
+
+func (p *slowPredictor) scored() []time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]time.Duration(nil), p.ends...)
+}
+
+// asyncHarness feeds an async engine one chunk per push, timing each push and
+// recording events.
+type asyncHarness struct {
+	t       *testing.T
+	e       *Engine
+	pred    *slowPredictor
+	events  []Event
+	slowest time.Duration
+}
+
+func newAsyncHarness(t *testing.T, delay time.Duration) *asyncHarness {
+	h := &asyncHarness{t: t, pred: &slowPredictor{delay: delay}}
+	cfg := testConfig(RequiredSampleRate, RequiredChunkSize)
+	cfg.AsyncTurnPrediction = true
+	cfg.VAD = levelVAD{}
+	cfg.TurnPredictor = h.pred
+	record := func(ev Event) { h.events = append(h.events, ev) }
+	e, err := New(cfg, Callbacks{
+		OnSpeechStart:    func(ev SpeechStart) { record(ev) },
+		OnSpeechEnd:      func(ev SpeechEnd) { record(ev) },
+		OnTurnPrediction: func(ev TurnPrediction) { record(ev) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Start()
+	h.e = e
+	return h
+}
+
+// push feeds n chunks of speech or silence.
+func (h *asyncHarness) push(n int, speech bool) {
+	chunk := make([]float32, RequiredChunkSize)
+	if speech {
+		for i := range chunk {
+			chunk[i] = 0.5
+		}
+	}
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		if err := h.e.PushSamples(chunk); err != nil {
+			h.t.Fatal(err)
+		}
+		if d := time.Since(start); d > h.slowest {
+			h.slowest = d
+		}
+	}
+}
+
+// kinds returns the event types in order, as "start", "turn" and "end".
+func (h *asyncHarness) kinds() []string {
+	var out []string
+	for _, ev := range h.events {
+		switch ev.(type) {
+		case SpeechStart:
+			out = append(out, "start")
+		case TurnPrediction:
+			out = append(out, "turn")
+		case SpeechEnd:
+			out = append(out, "end")
+		}
+	}
+	return out
+}
+
+func equalKinds(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestAsyncPredictionDoesNotBlock resumes speech again and again while a slow
+// prediction runs, faster than real time: no push may wait for inference,
+// stale segments are skipped, and only the last segment's result is reported.
+func TestAsyncPredictionDoesNotBlock(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	h := newAsyncHarness(t, delay)
+	defer h.e.Close()
+	const segments = 9
+	for i := 0; i < segments; i++ {
+		h.push(10, true)
+		h.push(3, false) // the segment ends after two chunks; speech resumes after three
+	}
+	h.push(40, false)
+	if h.slowest >= delay/2 {
+		t.Errorf("slowest push took %v with a %v predictor", h.slowest, delay)
+	}
+	if err := h.e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := h.kinds(), []string{"start", "turn", "end"}; !equalKinds(got, want) {
+		t.Fatalf("events %v, want %v", got, want)
+	}
+	last := 13*(segments-1)*32*time.Millisecond + 12*32*time.Millisecond
+	if tp := h.events[1].(TurnPrediction); tp.End != last {
+		t.Errorf("TurnPrediction.End = %v, want the last segment's end %v", tp.End, last)
+	}
+	scored := h.pred.scored()
+	if len(scored) >= segments || scored[len(scored)-1] != last {
+		t.Errorf("predictor scored segments ending at %v; want stale ones skipped and %v last", scored, last)
+	}
+}
+
+// TestAsyncPredictionOrder lets each prediction finish during the following
+// pause: every turn gets its prediction and then its SpeechEnd, in order,
+// delivered by a later push.
+func TestAsyncPredictionOrder(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	h := newAsyncHarness(t, delay)
+	defer h.e.Close()
+	for turn := 0; turn < 3; turn++ {
+		h.push(10, true)
+		h.push(2, false) // ends the segment
+		deadline := time.Now().Add(10 * time.Second)
+		for h.e.State().Predicting {
+			if time.Now().After(deadline) {
+				t.Fatal("prediction never delivered")
+			}
+			h.push(1, false)
+			time.Sleep(time.Millisecond)
+		}
+		h.push(5, false)
+	}
+	if h.slowest >= delay/2 {
+		t.Errorf("slowest push took %v with a %v predictor", h.slowest, delay)
+	}
+
+	want := []string{"start", "turn", "end", "start", "turn", "end", "start", "turn", "end"}
+	if got := h.kinds(); !equalKinds(got, want) {
+		t.Fatalf("events %v, want %v", got, want)
+	}
+	var prev time.Duration
+	for _, ev := range h.events {
+		if tp, ok := ev.(TurnPrediction); ok {
+			if tp.End <= prev {
+				t.Errorf("TurnPrediction ending at %v after one ending at %v", tp.End, prev)
+			}
+			prev = tp.End
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package smartturn
+
+// VoiceActivityDetector scores consecutive frames of mono audio at the VAD
+// rate: 512-sample chunks at 16 kHz, or 256-sample chunks at 8 kHz when
+// Config.ChunkSize is NarrowbandChunkSize. The engine calls it once per
+// chunk, in stream order, from a single
+// goroutine. Implementations may keep recurrent state between frames, so one
+// detector must not be shared by several engines.
+//
+// Silero VAD (Config.SileroVADModelPath) is the default; set Config.VAD to
+// plug in another detector or a scripted fake in tests.
+type VoiceActivityDetector interface {
+	// SpeechProbability returns the probability in [0, 1] that frame contains
+	// speech. frame has exactly Config.ChunkSize samples and must not be retained.
+	SpeechProbability(frame []float32) (float32, error)
+	// Reset clears any state carried between frames, as at the start of a stream.
+	Reset()
+	// Close releases resources. The engine calls it from Engine.Close.
+	Close() error
+}
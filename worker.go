@@ -0,0 +1,428 @@
+package smartturn
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy decides what Worker.Push and Worker.Write do when the input
+// queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until the worker frees a slot (the zero value).
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued audio buffer to make room.
+	// The buffer after the gap is realigned to the next whole frame (sample
+	// size × Channels), so decoding and channel order survive drops of any
+	// length.
+	OverflowDropOldest
+	// OverflowDropNewest discards the buffer being pushed, realigning the next
+	// one like OverflowDropOldest.
+	OverflowDropNewest
+	// OverflowError rejects the buffer being pushed with ErrOverflow.
+	OverflowError
+)
+
+var (
+	ErrOverflow       = errors.New("worker queue is full")
+	ErrWorkerStopped  = errors.New("worker is not running")
+	ErrWorkerRunning  = errors.New("worker is already running")
+	errWorkerNoFormat = errors.New("worker: Write requires WorkerConfig.Format")
+)
+
+// WorkerConfig configures a Worker.
+type WorkerConfig struct {
+	// QueueSize is the maximum number of queued audio buffers (Push or Write
+	// calls). Lifecycle requests are never dropped and do not count.
+	QueueSize int
+	// Overflow is applied when QueueSize buffers are already queued.
+	Overflow OverflowPolicy
+	// Format is the byte encoding accepted by Write. Leave zero if only Push is used.
+	Format SampleFormat
+}
+
+// Overflow is emitted by a Worker (OnOverflow and Events) when audio was
+// dropped or rejected because its queue was full.
+type Overflow struct {
+	Dropped int           // buffers dropped since the previous Overflow event
+	Samples int           // float32 samples (or bytes, for Write) in those buffers
+	At      time.Duration // stream time when the overflow was reported
+}
+
+func (Overflow) isEvent() {}
+
+// WorkerStats reports queue occupancy and lifetime drop counters.
+type WorkerStats struct {
+	Queued         int    // audio buffers waiting to be processed
+	Dropped        uint64 // audio buffers dropped or rejected since NewWorker
+	DroppedSamples uint64 // samples (bytes, for Write) in those buffers
+}
+
+type workerItem struct {
+	samples []float32
+	bytes   []byte
+	op      func(e *Engine)
+
+	// offset is the position of the buffer in the Push (samples) or Write
+	// (bytes) stream; gap marks the first buffer after dropped audio.
+	offset int64
+	gap    bool
+}
+
+// Worker makes an Engine usable from many goroutines. Push, Write and the
+// lifecycle methods may be called from any goroutine; they enqueue work that
+// Run executes in order on its own goroutine, which is the only one touching
+// the engine. Callbacks and Events are therefore delivered from Run.
+type Worker struct {
+	e    *Engine
+	cfg  WorkerConfig
+	pcm  *PCMWriter
+	base Config // engine config at NewWorker; its fixed fields never change
+
+	mu       sync.Mutex
+	space    *sync.Cond    // signalled when audio leaves the queue or the worker stops
+	notify   chan struct{} // wakes Run when work is queued
+	items    []workerItem
+	audio    int // audio items in items
+	running  bool
+	closing  bool // Close was called; Run drains the queue and returns
+	stopped  bool
+	stats    WorkerStats
+	unreport Overflow // drops not yet reported through an Overflow event
+	pushed   int64    // samples passed to Push, including dropped ones
+	written  int64    // bytes passed to Write, including dropped ones
+	gap      bool     // audio was dropped; the next queued buffer realigns
+	state    State    // engine state after the last processed item
+}
+
+// NewWorker wraps e. The engine must not be used directly while the worker is
+// running; use the worker's methods instead.
+func NewWorker(e *Engine, cfg WorkerConfig) (*Worker, error) {
+	if cfg.QueueSize <= 0 {
+		return nil, configError("QueueSize", "QueueSize must be > 0")
+	}
+	switch cfg.Overflow {
+	case OverflowBlock, OverflowDropOldest, OverflowDropNewest, OverflowError:
+	default:
+		return nil, configError("Overflow", "unknown OverflowPolicy")
+	}
+	w := &Worker{e: e, cfg: cfg, base: e.Config(), notify: make(chan struct{}, 1)}
+	w.space = sync.NewCond(&w.mu)
+	if cfg.Format != (SampleFormat{}) {
+		pcm, err := NewPCMWriter(e, cfg.Format)
+		if err != nil {
+			return nil, err
+		}
+		w.pcm = pcm
+	}
+	return w, nil
+}
+
+// Push queues a copy of samples for PushSamples. It follows the overflow
+// policy when the queue is full and returns ErrWorkerStopped once Run has returned.
+func (w *Worker) Push(samples []float32) error {
+	buf := workerSamplePool.Get().([]float32)
+	buf = append(buf[:0], samples...)
+	return w.enqueueAudio(workerItem{samples: buf}, len(samples))
+}
+
+// Write queues a copy of p for decoding in WorkerConfig.Format. It implements
+// io.Writer with the same overflow behaviour as Push; dropped bytes are still
+// reported as written, with the drop counted in Stats and an Overflow event.
+func (w *Worker) Write(p []byte) (int, error) {
+	if w.pcm == nil {
+		return 0, errWorkerNoFormat
+	}
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	if err := w.enqueueAudio(workerItem{bytes: buf}, len(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Start, Stop, Reset and Flush queue the matching Engine call behind any audio
+// already queued.
+func (w *Worker) Start() error { return w.enqueueOp(func(e *Engine) { e.Start() }) }
+
+func (w *Worker) Stop() error { return w.enqueueOp(func(e *Engine) { e.Stop() }) }
+
+func (w *Worker) Reset() error {
+	return w.enqueueRestart(func(e *Engine) {
+		if w.pcm != nil {
+			w.pcm.partialLen = 0
+		}
+		e.Reset()
+	})
+}
+
+func (w *Worker) Flush() error {
+	return w.enqueueRestart(func(e *Engine) {
+		if w.pcm != nil {
+			_ = w.pcm.Flush()
+		} else {
+			_ = e.Flush()
+		}
+	})
+}
+
+// UpdateConfig validates cfg now and queues Engine.UpdateConfig behind any
+// audio already queued.
+func (w *Worker) UpdateConfig(cfg Config) error {
+	if err := checkConfigUpdate(w.base, cfg); err != nil {
+		return err
+	}
+	return w.enqueueOp(func(e *Engine) {
+		if err := e.UpdateConfig(cfg); err != nil {
+			e.emitError(err)
+		}
+	})
+}
+
+// Close stops accepting input. Run finishes the work already queued and then
+// returns nil. Close does not close the engine.
+func (w *Worker) Close() {
+	w.mu.Lock()
+	w.closing = true
+	w.wake()
+	w.mu.Unlock()
+	w.space.Broadcast()
+}
+
+// Stats returns a snapshot of the queue and drop counters.
+func (w *Worker) Stats() WorkerStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	st := w.stats
+	st.Queued = w.audio
+	return st
+}
+
+// State returns the engine's State as of the last audio buffer or lifecycle
+// request Run processed.
+func (w *Worker) State() State {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.state
+}
+
+// Run processes queued work until ctx is done, returning ctx.Err(), or until
+// Close was called and the queue is drained, returning nil. It must be called
+// exactly once; after it returns, Push, Write and the lifecycle methods fail
+// with ErrWorkerStopped and any work still queued is discarded. Engine errors
+// are reported through OnError/Events and do not stop Run.
+func (w *Worker) Run(ctx context.Context) error {
+	w.mu.Lock()
+	if w.running || w.stopped {
+		w.mu.Unlock()
+		return ErrWorkerRunning
+	}
+	w.running = true
+	w.mu.Unlock()
+	defer w.stop()
+
+	for {
+		w.mu.Lock()
+		report := w.unreport
+		w.unreport = Overflow{}
+		var item workerItem
+		ok := len(w.items) > 0
+		closing := w.closing
+		if ok {
+			item = w.items[0]
+			w.items[0] = workerItem{}
+			w.items = w.items[1:]
+			if item.op == nil {
+				w.audio--
+				w.space.Signal()
+			}
+		}
+		w.mu.Unlock()
+
+		if report.Dropped > 0 {
+			report.At = w.e.streamTime(w.e.streamPos)
+			w.e.emit(report)
+		}
+		if !ok {
+			if closing {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-w.notify:
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if item.gap {
+			w.realign(&item)
+		}
+		switch {
+		case item.op != nil:
+			item.op(w.e)
+		case item.bytes != nil:
+			_, _ = w.pcm.Write(item.bytes)
+		default:
+			_ = w.e.PushSamples(item.samples)
+			workerSamplePool.Put(item.samples)
+		}
+		st := w.e.State()
+		w.mu.Lock()
+		w.state = st
+		w.mu.Unlock()
+	}
+}
+
+// workerSamplePool reuses Push copies between producers and Run.
+var workerSamplePool = sync.Pool{
+	New: func() interface{} { return make([]float32, 0, RequiredChunkSize) },
+}
+
+func (w *Worker) enqueueAudio(item workerItem, n int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for !w.stopped && !w.closing && w.audio >= w.cfg.QueueSize {
+		switch w.cfg.Overflow {
+		case OverflowBlock:
+			w.space.Wait()
+			continue
+		case OverflowDropOldest:
+			w.dropOldest()
+			continue
+		case OverflowDropNewest:
+			w.advance(&item, n)
+			w.recordDrop(n)
+			w.gap = true
+			return nil
+		default:
+			w.recordDrop(n)
+			return ErrOverflow
+		}
+	}
+	if w.stopped || w.closing {
+		return ErrWorkerStopped
+	}
+	w.advance(&item, n)
+	item.gap, w.gap = w.gap, false
+	w.items = append(w.items, item)
+	w.audio++
+	w.wake()
+	return nil
+}
+
+// advance assigns item its stream offset and moves the stream past its n
+// samples or bytes. Caller holds w.mu.
+func (w *Worker) advance(item *workerItem, n int) {
+	if item.bytes != nil {
+		item.offset = w.written
+		w.written += int64(n)
+	} else {
+		item.offset = w.pushed
+		w.pushed += int64(n)
+	}
+}
+
+// dropOldest drops the oldest queued audio buffer and marks the audio after
+// it for realignment. Caller holds w.mu.
+func (w *Worker) dropOldest() {
+	for i, old := range w.items {
+		if old.op != nil {
+			continue
+		}
+		w.recordDrop(len(old.samples) + len(old.bytes))
+		if old.samples != nil {
+			workerSamplePool.Put(old.samples)
+		}
+		w.items = append(w.items[:i], w.items[i+1:]...)
+		w.audio--
+		for j := i; j < len(w.items); j++ {
+			if w.items[j].op == nil {
+				w.items[j].gap = true
+				return
+			}
+		}
+		w.gap = true
+		return
+	}
+}
+
+// realign discards the partial sample and frame left by audio before a drop
+// and skips item to the next whole frame of its stream. Run calls it before
+// processing item.
+func (w *Worker) realign(item *workerItem) {
+	if w.pcm != nil {
+		w.pcm.partialLen = 0
+	}
+	if w.e.mixer != nil {
+		w.e.mixer.partialLen = 0
+	}
+	frame := int64(w.base.Channels)
+	buf := len(item.samples)
+	if item.bytes != nil {
+		frame *= int64(w.pcm.size)
+		buf = len(item.bytes)
+	}
+	skip := min(int((frame-item.offset%frame)%frame), buf)
+	if item.bytes != nil {
+		item.bytes = item.bytes[skip:]
+	} else {
+		item.samples = item.samples[skip:]
+	}
+}
+
+func (w *Worker) enqueueOp(op func(e *Engine)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped || w.closing {
+		return ErrWorkerStopped
+	}
+	w.items = append(w.items, workerItem{op: op})
+	w.wake()
+	return nil
+}
+
+// enqueueRestart queues op like enqueueOp for Flush and Reset, which discard
+// any incomplete frame, so audio after them starts a new frame-aligned stream.
+func (w *Worker) enqueueRestart(op func(e *Engine)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped || w.closing {
+		return ErrWorkerStopped
+	}
+	w.items = append(w.items, workerItem{op: op})
+	w.pushed, w.written = 0, 0
+	w.wake()
+	return nil
+}
+
+// recordDrop counts a dropped buffer of n samples. Caller holds w.mu.
+func (w *Worker) recordDrop(n int) {
+	w.stats.Dropped++
+	w.stats.DroppedSamples += uint64(n)
+	w.unreport.Dropped++
+	w.unreport.Samples += n
+	w.wake()
+}
+
+// wake signals Run without blocking. Caller holds w.mu.
+func (w *Worker) wake() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Worker) stop() {
+	w.mu.Lock()
+	w.stopped = true
+	w.items = nil
+	w.audio = 0
+	w.mu.Unlock()
+	w.space.Broadcast()
+}
@@ -0,0 +1,323 @@
+package smartturn
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// runDropped queues the Start request and then every buffer through push on
+// a DropOldest worker with QueueSize 2, so only the last two buffers survive,
+// then runs it and returns the mono audio the engine processed.
+func runDropped(t *testing.T, cfg WorkerConfig, channels int, push func(w *Worker) error) []float32 {
+	t.Helper()
+	c := testConfig(RequiredSampleRate, RequiredChunkSize)
+	c.Channels = channels
+	c.ChannelPolicy = ChannelSelect
+	c.VAD = indexVAD{}
+	c.TurnPredictor = &recordPredictor{}
+	var got []float32
+	e, err := New(c, Callbacks{OnChunk: func(ev Chunk) { got = append(got, ev.Audio...) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	w, err := NewWorker(e, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := push(w); err != nil {
+		t.Fatal(err)
+	}
+	if st := w.Stats(); st.Dropped != 3 {
+		t.Fatalf("Dropped = %d, want 3", st.Dropped)
+	}
+	w.Close()
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) < RequiredChunkSize {
+		t.Fatalf("processed %d samples, want at least a chunk", len(got))
+	}
+	return got
+}
+
+// TestWorkerDropRealignsWrite drops writes whose lengths are not whole s16
+// stereo frames; the surviving audio must still decode as channel 0.
+func TestWorkerDropRealignsWrite(t *testing.T) {
+	const frames = 2000
+	stream := make([]byte, 0, (5*frames+2)*4)
+	for i := 0; i < 5*frames+2; i++ {
+		stream = binary.LittleEndian.AppendUint16(stream, uint16(0x4000)) // left: 0.5
+		stream = binary.LittleEndian.AppendUint16(stream, uint16(0xc000)) // right: -0.5
+	}
+	const piece = frames*4 + 1
+	got := runDropped(t, WorkerConfig{QueueSize: 2, Overflow: OverflowDropOldest, Format: FormatS16LE}, 2, func(w *Worker) error {
+		for i := 0; i < 5; i++ {
+			if _, err := w.Write(stream[i*piece : (i+1)*piece]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for i, x := range got {
+		if x != 0.5 {
+			t.Fatalf("sample %d = %v, want 0.5", i, x)
+		}
+	}
+}
+
+// TestWorkerDropRealignsPush drops interleaved stereo buffers holding an odd
+// number of samples; channel 0 must stay channel 0.
+func TestWorkerDropRealignsPush(t *testing.T) {
+	const piece = 2*1000 + 1
+	stream := make([]float32, 5*piece+1)
+	for i := range stream {
+		stream[i] = 0.5
+		if i%2 == 1 {
+			stream[i] = -0.5
+		}
+	}
+	got := runDropped(t, WorkerConfig{QueueSize: 2, Overflow: OverflowDropOldest}, 2, func(w *Worker) error {
+		for i := 0; i < 5; i++ {
+			if err := w.Push(stream[i*piece : (i+1)*piece]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for i, x := range got {
+		if x != 0.5 {
+			t.Fatalf("sample %d = %v, want 0.5", i, x)
+		}
+	}
+}
+
+// TestWorkerDropNewestRealigns drops a write while Run is idle; the next
+// write must be realigned too.
+func TestWorkerDropNewestRealigns(t *testing.T) {
+	c := testConfig(RequiredSampleRate, RequiredChunkSize)
+	c.Channels = 2
+	c.ChannelPolicy = ChannelSelect
+	c.VAD = indexVAD{}
+	c.TurnPredictor = &recordPredictor{}
+	var got []float32
+	e, err := New(c, Callbacks{OnChunk: func(ev Chunk) { got = append(got, ev.Audio...) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	w, err := NewWorker(e, WorkerConfig{QueueSize: 1, Overflow: OverflowDropNewest, Format: FormatF32LE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := binary.LittleEndian.AppendUint32(nil, 0x3f000000)  // left: 0.5
+	frame = binary.LittleEndian.AppendUint32(frame, 0xbf000000) // right: -0.5
+	var stream []byte
+	for len(stream) < 3*4003 {
+		stream = append(stream, frame...)
+	}
+	write := func(i int) {
+		if _, err := w.Write(stream[i*4003 : (i+1)*4003]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = w.Start()
+	write(0)
+	write(1) // dropped: the queue is full
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	for w.Stats().Queued > 0 {
+		runtime.Gosched()
+	}
+	write(2)
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if st := w.Stats(); st.Dropped != 1 {
+		t.Fatalf("Dropped = %d, want 1", st.Dropped)
+	}
+	if len(got) < RequiredChunkSize {
+		t.Fatalf("processed %d samples, want at least a chunk", len(got))
+	}
+	for i, x := range got {
+		if x != 0.5 {
+			t.Fatalf("sample %d = %v, want 0.5", i, x)
+		}
+	}
+}
+
+// newTestWorker returns a worker over a started engine with channels
+// ChannelSelect input; the engine is closed when the test ends.
+func newTestWorker(t *testing.T, channels int, cfg WorkerConfig, cb Callbacks) *Worker {
+	t.Helper()
+	c := testConfig(RequiredSampleRate, RequiredChunkSize)
+	c.Channels = channels
+	c.ChannelPolicy = ChannelSelect
+	c.VAD = indexVAD{}
+	c.TurnPredictor = &recordPredictor{}
+	e, err := New(c, cb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+	w, err := NewWorker(e, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// TestWorkerResetRealigns resets after half an s16 stereo frame: the writes
+// after Reset must start a new frame, whether the half frame is decoded or
+// dropped along with the first write after it.
+func TestWorkerResetRealigns(t *testing.T) {
+	frame := binary.LittleEndian.AppendUint16(nil, 0x4000)  // left: 0.5
+	frame = binary.LittleEndian.AppendUint16(frame, 0xc000) // right: -0.5
+	piece := bytes.Repeat(frame, 2000)
+	for _, queue := range []int{8, 2} {
+		var got []float32
+		w := newTestWorker(t, 2, WorkerConfig{QueueSize: queue, Overflow: OverflowDropOldest, Format: FormatS16LE},
+			Callbacks{OnChunk: func(ev Chunk) { got = append(got, ev.Audio...) }})
+		if _, err := w.Write(frame[:3]); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Reset(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Start(); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := w.Write(piece); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.Close()
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) < RequiredChunkSize {
+			t.Fatalf("QueueSize %d: processed %d samples, want at least a chunk", queue, len(got))
+		}
+		for i, x := range got {
+			if x != 0.5 {
+				t.Fatalf("QueueSize %d: sample %d = %v, want 0.5", queue, i, x)
+			}
+		}
+	}
+}
+
+// TestWorkerOverflowBlock blocks a producer on a full queue until Run frees a
+// slot; nothing is dropped.
+func TestWorkerOverflowBlock(t *testing.T) {
+	var chunks int
+	w := newTestWorker(t, 1, WorkerConfig{QueueSize: 1, Overflow: OverflowBlock},
+		Callbacks{OnChunk: func(Chunk) { chunks++ }})
+	buf := make([]float32, RequiredChunkSize)
+	if err := w.Push(buf); err != nil {
+		t.Fatal(err)
+	}
+	pushed := make(chan error)
+	go func() { pushed <- w.Push(buf) }()
+	select {
+	case err := <-pushed:
+		t.Fatalf("Push on a full queue returned %v before Run started", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	if err := <-pushed; err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 2 {
+		t.Errorf("processed %d chunks, want 2", chunks)
+	}
+	if st := w.Stats(); st != (WorkerStats{}) {
+		t.Errorf("Stats = %+v, want zero", st)
+	}
+}
+
+// TestWorkerOverflowError rejects pushes on a full queue with ErrOverflow,
+// counts them in Stats, and reports them in a single Overflow event.
+func TestWorkerOverflowError(t *testing.T) {
+	var events []Event
+	w := newTestWorker(t, 1, WorkerConfig{QueueSize: 1, Overflow: OverflowError}, recordEvents(&events))
+	if err := w.Push(make([]float32, RequiredChunkSize)); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{100, 200} {
+		if err := w.Push(make([]float32, n)); !errors.Is(err, ErrOverflow) {
+			t.Fatalf("Push on a full queue = %v, want ErrOverflow", err)
+		}
+	}
+	if st, want := w.Stats(), (WorkerStats{Queued: 1, Dropped: 2, DroppedSamples: 300}); st != want {
+		t.Errorf("Stats = %+v, want %+v", st, want)
+	}
+	w.Close()
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var overflows []Overflow
+	for _, ev := range events {
+		if o, ok := ev.(Overflow); ok {
+			overflows = append(overflows, o)
+		}
+	}
+	if want := []Overflow{{Dropped: 2, Samples: 300}}; len(overflows) != 1 || overflows[0] != want[0] {
+		t.Errorf("Overflow events %+v, want %+v", overflows, want)
+	}
+	if st, want := w.Stats(), (WorkerStats{Dropped: 2, DroppedSamples: 300}); st != want {
+		t.Errorf("Stats after Run = %+v, want %+v", st, want)
+	}
+}
+
+// TestWorkerRunCancel ends Run when its context is cancelled; afterwards the
+// worker rejects input and lifecycle requests.
+func TestWorkerRunCancel(t *testing.T) {
+	w := newTestWorker(t, 1, WorkerConfig{QueueSize: 1, Overflow: OverflowBlock, Format: FormatS16LE}, Callbacks{})
+	buf := make([]float32, RequiredChunkSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	if err := w.Push(buf); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+
+	if err := w.Push(buf); !errors.Is(err, ErrWorkerStopped) {
+		t.Errorf("Push = %v, want ErrWorkerStopped", err)
+	}
+	if _, err := w.Write([]byte{0, 0}); !errors.Is(err, ErrWorkerStopped) {
+		t.Errorf("Write = %v, want ErrWorkerStopped", err)
+	}
+	for name, op := range map[string]func() error{
+		"Start": w.Start, "Stop": w.Stop, "Reset": w.Reset, "Flush": w.Flush,
+	} {
+		if err := op(); !errors.Is(err, ErrWorkerStopped) {
+			t.Errorf("%s = %v, want ErrWorkerStopped", name, err)
+		}
+	}
+	if err := w.Run(context.Background()); !errors.Is(err, ErrWorkerRunning) {
+		t.Errorf("second Run = %v, want ErrWorkerRunning", err)
+	}
+}